@@ -6,6 +6,7 @@ import (
 	"TestEffectiveMobile/cmd/internal/logger"
 	"TestEffectiveMobile/cmd/internal/repository"
 	"TestEffectiveMobile/cmd/internal/service"
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/gorilla/mux"
@@ -44,17 +45,70 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Создаем репозиторий
+	// Создаем репозитории
 	repo := repository.NewPersonRepositoryPgSQL(db)
+	jobs := repository.NewJobRepositoryPgSQL(db)
 
-	// Создаем сервис
-	ps := service.NewPersonService(repo)
+	// Собираем источники обогащения в порядке из конфигурации
+	var sources []service.Enricher
+	var quotas []*service.QuotaTracker
+	for _, provider := range cfg.Enrichment.Providers {
+		switch provider {
+		case config.ProviderLocal:
+			local, err := service.NewLocalProvider(cfg.Enrichment.LocalDataset)
+			if err != nil {
+				slog.Error("Ошибка загрузки локального справочника имен", "error", err)
+				os.Exit(1)
+			}
+			sources = append(sources, service.NewLocalEnricher(local))
+		case config.ProviderRemote:
+			httpEnricher, httpQuotas := service.NewHTTPEnricher(&cfg.Enrichment)
+			sources, quotas = append(sources, httpEnricher), append(quotas, httpQuotas...)
+		}
+	}
+
+	// Кешируем результаты обогащения в памяти и, при необходимости, в БД
+	caches := []service.EnrichmentCache{service.NewLRUCache(cfg.Enrichment.CacheSize, cfg.Enrichment.CacheTTL)}
+	if cfg.Enrichment.CachePersistent {
+		cacheRepo := repository.NewEnrichmentCacheRepositoryPgSQL(db)
+		caches = append(caches, service.NewPersistentCache(cacheRepo, cfg.Enrichment.PersistentCacheTTL))
+	}
+	cachingEnricher := service.NewCachingEnricher(service.NewChainEnricher(sources...), caches...)
+
+	// При двухпроходной стратегии возраст и пол уточняются по определенной национальности
+	var enricher service.Enricher = cachingEnricher
+	if cfg.Enrichment.CountryStrategy == config.CountryStrategyTwoPass {
+		enricher = service.NewTwoPassEnricher(cachingEnricher)
+	}
+
+	// Создаем сервисы
+	ps := service.NewPersonService(repo, jobs, enricher, &cfg.Enrichment)
+	reenricher := service.NewReenricher(repo, enricher, &cfg.Enrichment)
+	as := service.NewAdminService(cachingEnricher, quotas, reenricher)
+
+	// Подкоманда reenrich выполняет повторное обогащение и завершает работу без запуска сервера
+	if len(os.Args) > 1 && os.Args[1] == "reenrich" {
+		os.Exit(runReenrich(reenricher, os.Args[2:]))
+	}
+
+	// Нормализованные имена людей, сохраненных до их появления, заполняются в фоне
+	go func() {
+		if _, err := service.BackfillNormalizedNames(context.Background(), repo); err != nil {
+			slog.Error("Ошибка заполнения нормализованных имен", "error", err)
+		}
+	}()
+
+	// Запуск воркеров фонового обогащения
+	if cfg.Enrichment.WorkerCount > 0 {
+		worker := service.NewEnrichmentWorker(repo, jobs, enricher, &cfg.Enrichment)
+		go worker.Run(context.Background())
+	}
 
 	// Настройка маршрутов с использованием Gorilla Mux
 	r := mux.NewRouter()
 
 	// Регистрация маршрутов
-	handler.SetupRoutes(r, handler.NewPersonHandler(ps))
+	handler.SetupRoutes(r, handler.NewPersonHandler(ps), handler.NewAdminHandler(as), cfg.Server.AdminToken)
 
 	// Старт сервера
 	slog.Warn(fmt.Sprintf("Сервер запущен и прослушивает порт %s\n", cfg.Server.Port))
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"TestEffectiveMobile/cmd/internal/service"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// runReenrich разбирает флаги подкоманды reenrich, выполняет повторное обогащение
+// и печатает итог в JSON. Возвращает код завершения процесса.
+func runReenrich(reenricher *service.Reenricher, args []string) int {
+	flags := flag.NewFlagSet("reenrich", flag.ContinueOnError)
+	fromID := flags.Int("from-id", 0, "Минимальный id человека")
+	toID := flags.Int("to-id", 0, "Максимальный id человека")
+	missing := flags.String("missing", "", "Атрибуты через запятую, хотя бы один из которых не заполнен (age,gender,nationality)")
+	sources := flags.String("sources", "", "Источники через запятую, из которых получен хотя бы один атрибут (agify,genderize,...)")
+	olderThan := flags.Duration("older-than", 0, "Только люди, обогащенные раньше, чем столько времени назад")
+	limit := flags.Int("limit", 0, "Максимальное число людей, 0 без ограничений")
+	dryRun := flags.Bool("dry-run", false, "Только показать, что изменится")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	opts := service.ReenrichOptions{
+		Filter: model.ReenrichFilter{
+			FromID:  *fromID,
+			ToID:    *toID,
+			Missing: splitList(*missing),
+			Sources: splitList(*sources),
+			Limit:   *limit,
+		},
+		DryRun: *dryRun,
+	}
+	if *olderThan > 0 {
+		opts.Filter.EnrichedBefore = time.Now().Add(-*olderThan)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := reenricher.Run(ctx, opts, func(report service.ReenrichReport) {
+		fmt.Fprintf(os.Stderr, "обработано %d, изменено %d, ошибок %d\n", report.Processed, report.Changed, report.Failed)
+	})
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if encErr := encoder.Encode(report); encErr != nil {
+		fmt.Fprintf(os.Stderr, "ошибка вывода итога: %v\n", encErr)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ошибка повторного обогащения: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// splitList разбирает список через запятую, пропуская пустые элементы
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"TestEffectiveMobile/cmd/internal/fakeapi"
+	"flag"
+	"log"
+	"log/slog"
+	"net/http"
+)
+
+// Подменный сервер agify, genderize и nationalize для интеграционных тестов и запуска без интернета
+func main() {
+	addr := flag.String("addr", ":8090", "Адрес HTTP-сервера")
+	seed := flag.Uint64("seed", 1, "Seed для ответов и сбоев")
+	errorRate := flag.Float64("error-rate", 0, "Доля ответов 500")
+	rateLimitRate := flag.Float64("rate-limit-rate", 0, "Доля ответов 429")
+	latency := flag.Duration("latency", 0, "Задержка перед каждым ответом")
+	quota := flag.Int("quota", 0, "Число запросов до исчерпания квоты, 0 без ограничений")
+	flag.Parse()
+
+	server := fakeapi.NewServer(fakeapi.Options{
+		Seed:          *seed,
+		ErrorRate:     *errorRate,
+		RateLimitRate: *rateLimitRate,
+		Latency:       *latency,
+		Quota:         *quota,
+	})
+
+	slog.Info("Подменный сервер внешних API запущен", "addr", *addr, "seed", *seed)
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		log.Fatalf("ошибка запуска сервера: %v", err)
+	}
+}
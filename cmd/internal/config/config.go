@@ -3,17 +3,21 @@ package config
 import (
 	"fmt"
 	"github.com/joho/godotenv"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Config содержит все конфигурационные параметры приложения
 type Config struct {
-	DB     DBConfig     // Настройки базы данных
-	Server ServerConfig // Настройки сервера
-	Log    LogConfig    // Настройки логирования
-	Env    string       // Текущее окружение (development, production, test)
+	DB         DBConfig         // Настройки базы данных
+	Server     ServerConfig     // Настройки сервера
+	Log        LogConfig        // Настройки логирования
+	Enrichment EnrichmentConfig // Настройки обогащения данных
+	Env        string           // Текущее окружение (development, production, test)
 }
 
 // DBConfig содержит параметры подключения к базе данных
@@ -28,7 +32,8 @@ type DBConfig struct {
 
 // ServerConfig содержит настройки HTTP-сервера
 type ServerConfig struct {
-	Port string // Порт для HTTP-сервера 	// Время для фоновой джобы очиски задач
+	Port       string // Порт для HTTP-сервера 	// Время для фоновой джобы очиски задач
+	AdminToken string // Токен служебных маршрутов /admin/; пустой отключает их
 }
 
 // LogConfig содержит настройки логирования
@@ -38,6 +43,89 @@ type LogConfig struct {
 	Environment string // Окружение для формата логов
 }
 
+// EnrichmentConfig содержит настройки обогащения данных из внешних API
+type EnrichmentConfig struct {
+	Policy             string        // Политика при ошибках обогащения (strict, best-effort, async)
+	Timeout            time.Duration // Максимальное время обогащения одного человека
+	CacheSize          int           // Число записей в кеше обогащения в памяти
+	CacheTTL           time.Duration // Время жизни записи в кеше в памяти
+	CachePersistent    bool          // Хранить результаты обогащения в БД
+	PersistentCacheTTL time.Duration // Время жизни записи в кеше в БД
+
+	RetryMaxAttempts        int           // Число попыток запроса к внешнему API
+	RetryBaseDelay          time.Duration // Начальная задержка между попытками
+	RetryMaxDelay           time.Duration // Максимальная задержка между попытками
+	BreakerFailureThreshold int           // Число отказов подряд, после которого провайдер отключается
+	BreakerOpenTimeout      time.Duration // Время, на которое отключается провайдер
+	CircuitOpenMode         string        // Поведение при отключенном провайдере (fail, degrade)
+
+	WorkerCount        int           // Число воркеров фонового обогащения
+	WorkerPollInterval time.Duration // Интервал опроса пустой очереди заданий
+	JobMaxAttempts     int           // Число попыток задания до переноса в dead-letter
+	JobRetryDelay      time.Duration // Начальная задержка перед повтором задания
+	JobLease           time.Duration // Время, после которого зависшее задание возвращается в очередь
+
+	MinAgeCount               int     // Минимальный размер выборки для возраста
+	MinGenderProbability      float64 // Минимальная вероятность пола
+	MinNationalityProbability float64 // Минимальная вероятность национальности
+	LowConfidenceMode         string  // Что делать со значениями ниже порога (reject, flag)
+
+	CountryStrategy string // Уточнение возраста и пола по стране (none, hint, two-pass)
+
+	Providers    []string // Порядок опроса источников обогащения (local, remote)
+	LocalDataset string   // CSV-файл локального справочника имен, по умолчанию встроенный
+
+	AgifyURL       string // Базовый адрес agify
+	GenderizeURL   string // Базовый адрес genderize
+	NationalizeURL string // Базовый адрес nationalize
+
+	AgifyAPIKey           string        // Ключ платного тарифа agify
+	GenderizeAPIKey       string        // Ключ платного тарифа genderize
+	NationalizeAPIKey     string        // Ключ платного тарифа nationalize
+	QuotaLowWatermark     int           // Остаток квоты, начиная с которого включается защита
+	QuotaLowMode          string        // Что делать при низком остатке квоты (throttle, fallback)
+	QuotaThrottleInterval time.Duration // Минимальный интервал между запросами при низком остатке
+	QuotaResetWindow      time.Duration // Через сколько пробовать запрос снова, если провайдер не сообщил время обновления квоты
+}
+
+// Политики обогащения
+const (
+	PolicyStrict     = "strict"      // Не сохранять человека, если обогащение не удалось
+	PolicyBestEffort = "best-effort" // Сохранить человека с тем, что удалось получить
+	PolicyAsync      = "async"       // Сохранить человека сразу и обогатить в фоне
+)
+
+// Режимы обработки недостаточно достоверных значений
+const (
+	LowConfidenceReject = "reject" // Не сохранять значение
+	LowConfidenceFlag   = "flag"   // Сохранить значение с пометкой
+)
+
+// Стратегии уточнения возраста и пола по стране
+const (
+	CountryStrategyNone    = "none"     // Не уточнять
+	CountryStrategyHint    = "hint"     // Уточнять по стране из запроса или известной национальности
+	CountryStrategyTwoPass = "two-pass" // Как hint, а без подсказки сначала определять национальность
+)
+
+// Источники обогащения
+const (
+	ProviderLocal  = "local"  // Локальный справочник имен без обращения к сети
+	ProviderRemote = "remote" // agify, genderize и nationalize
+)
+
+// Поведение при низком остатке квоты внешнего API
+const (
+	QuotaLowThrottle = "throttle" // Замедлять запросы
+	QuotaLowFallback = "fallback" // Переходить к следующему источнику обогащения
+)
+
+// Режимы работы при отключенном провайдере
+const (
+	CircuitOpenFail    = "fail"    // Сразу вернуть ошибку
+	CircuitOpenDegrade = "degrade" // Сохранить человека без данных отключенного провайдера
+)
+
 // GetLogDir возвращает директорию для логов
 func (c *LogConfig) GetLogDir() string {
 	return filepath.Dir(c.FilePath)
@@ -76,13 +164,57 @@ func NewConfig() *Config {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", ":8080"),
+			Port:       getEnv("SERVER_PORT", ":8080"),
+			AdminToken: getEnv("ADMIN_TOKEN", ""),
 		},
 		Log: LogConfig{
 			Level:       getEnv("LOG_LEVEL", "INFO"),
 			FilePath:    filepath.Join(rootDir, getEnv("LOG_FILE", "logs/app.log")),
 			Environment: getEnv("ENVIRONMENT", "development"),
 		},
+		Enrichment: EnrichmentConfig{
+			Policy:             getEnv("ENRICHMENT_POLICY", PolicyStrict),
+			Timeout:            getEnvAsDuration("ENRICHMENT_TIMEOUT", 5*time.Second),
+			CacheSize:          getEnvAsInt("ENRICHMENT_CACHE_SIZE", 10000),
+			CacheTTL:           getEnvAsDuration("ENRICHMENT_CACHE_TTL", time.Hour),
+			CachePersistent:    getEnvAsBool("ENRICHMENT_CACHE_PERSISTENT", true),
+			PersistentCacheTTL: getEnvAsDuration("ENRICHMENT_CACHE_PERSISTENT_TTL", 30*24*time.Hour),
+
+			RetryMaxAttempts:        getEnvAsInt("ENRICHMENT_RETRY_ATTEMPTS", 3),
+			RetryBaseDelay:          getEnvAsDuration("ENRICHMENT_RETRY_BASE_DELAY", 200*time.Millisecond),
+			RetryMaxDelay:           getEnvAsDuration("ENRICHMENT_RETRY_MAX_DELAY", 2*time.Second),
+			BreakerFailureThreshold: getEnvAsInt("ENRICHMENT_BREAKER_FAILURES", 5),
+			BreakerOpenTimeout:      getEnvAsDuration("ENRICHMENT_BREAKER_OPEN_TIMEOUT", 30*time.Second),
+			CircuitOpenMode:         getEnv("ENRICHMENT_CIRCUIT_OPEN_MODE", CircuitOpenFail),
+
+			WorkerCount:        getEnvAsInt("ENRICHMENT_WORKERS", 2),
+			WorkerPollInterval: getEnvAsDuration("ENRICHMENT_WORKER_POLL_INTERVAL", time.Second),
+			JobMaxAttempts:     getEnvAsInt("ENRICHMENT_JOB_MAX_ATTEMPTS", 5),
+			JobRetryDelay:      getEnvAsDuration("ENRICHMENT_JOB_RETRY_DELAY", 10*time.Second),
+			JobLease:           getEnvAsDuration("ENRICHMENT_JOB_LEASE", 5*time.Minute),
+
+			MinAgeCount:               getEnvAsInt("ENRICHMENT_MIN_AGE_COUNT", 0),
+			MinGenderProbability:      getEnvAsFloat("ENRICHMENT_MIN_GENDER_PROBABILITY", 0),
+			MinNationalityProbability: getEnvAsFloat("ENRICHMENT_MIN_NATIONALITY_PROBABILITY", 0),
+			LowConfidenceMode:         getEnv("ENRICHMENT_LOW_CONFIDENCE_MODE", LowConfidenceReject),
+
+			CountryStrategy: getEnv("ENRICHMENT_COUNTRY_STRATEGY", CountryStrategyHint),
+
+			Providers:    getEnvAsList("ENRICHMENT_PROVIDERS", []string{ProviderRemote}),
+			LocalDataset: getEnv("ENRICHMENT_LOCAL_DATASET", ""),
+
+			AgifyURL:       getEnv("ENRICHMENT_AGIFY_URL", "https://api.agify.io/"),
+			GenderizeURL:   getEnv("ENRICHMENT_GENDERIZE_URL", "https://api.genderize.io/"),
+			NationalizeURL: getEnv("ENRICHMENT_NATIONALIZE_URL", "https://api.nationalize.io/"),
+
+			AgifyAPIKey:           getEnv("ENRICHMENT_AGIFY_API_KEY", ""),
+			GenderizeAPIKey:       getEnv("ENRICHMENT_GENDERIZE_API_KEY", ""),
+			NationalizeAPIKey:     getEnv("ENRICHMENT_NATIONALIZE_API_KEY", ""),
+			QuotaLowWatermark:     getEnvAsInt("ENRICHMENT_QUOTA_LOW_WATERMARK", 10),
+			QuotaLowMode:          getEnv("ENRICHMENT_QUOTA_LOW_MODE", QuotaLowThrottle),
+			QuotaThrottleInterval: getEnvAsDuration("ENRICHMENT_QUOTA_THROTTLE_INTERVAL", time.Second),
+			QuotaResetWindow:      getEnvAsDuration("ENRICHMENT_QUOTA_RESET_WINDOW", time.Hour),
+		},
 		Env: getEnv("ENVIRONMENT", "development"),
 	}
 
@@ -117,6 +249,82 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("недопустимый уровень логирования: %s", c.Log.Level)
 	}
 
+	// Проверка настроек обогащения
+	validPolicies := map[string]bool{PolicyStrict: true, PolicyBestEffort: true, PolicyAsync: true}
+	if !validPolicies[c.Enrichment.Policy] {
+		return fmt.Errorf("недопустимая политика обогащения: %s", c.Enrichment.Policy)
+	}
+	if c.Enrichment.Timeout <= 0 {
+		return fmt.Errorf("таймаут обогащения должен быть положительным")
+	}
+	if c.Enrichment.CacheSize <= 0 {
+		return fmt.Errorf("размер кеша обогащения должен быть положительным")
+	}
+	if c.Enrichment.CacheTTL <= 0 || c.Enrichment.PersistentCacheTTL <= 0 {
+		return fmt.Errorf("время жизни кеша обогащения должно быть положительным")
+	}
+	if c.Enrichment.RetryMaxAttempts <= 0 {
+		return fmt.Errorf("число попыток запроса к внешнему API должно быть положительным")
+	}
+	if c.Enrichment.RetryBaseDelay <= 0 || c.Enrichment.RetryMaxDelay < c.Enrichment.RetryBaseDelay {
+		return fmt.Errorf("недопустимые задержки между попытками запроса к внешнему API")
+	}
+	if c.Enrichment.BreakerFailureThreshold <= 0 || c.Enrichment.BreakerOpenTimeout <= 0 {
+		return fmt.Errorf("недопустимые настройки отключения провайдеров")
+	}
+	validCircuitModes := map[string]bool{CircuitOpenFail: true, CircuitOpenDegrade: true}
+	if !validCircuitModes[c.Enrichment.CircuitOpenMode] {
+		return fmt.Errorf("недопустимый режим при отключенном провайдере: %s", c.Enrichment.CircuitOpenMode)
+	}
+	if c.Enrichment.WorkerCount < 0 || c.Enrichment.WorkerPollInterval <= 0 {
+		return fmt.Errorf("недопустимые настройки воркеров обогащения")
+	}
+	if c.Enrichment.JobMaxAttempts <= 0 || c.Enrichment.JobRetryDelay <= 0 {
+		return fmt.Errorf("недопустимые настройки повторов заданий на обогащение")
+	}
+	if c.Enrichment.JobLease <= c.Enrichment.Timeout {
+		return fmt.Errorf("время блокировки задания должно превышать таймаут обогащения")
+	}
+	if c.Enrichment.MinAgeCount < 0 {
+		return fmt.Errorf("минимальный размер выборки для возраста не может быть отрицательным")
+	}
+	for _, p := range []float64{c.Enrichment.MinGenderProbability, c.Enrichment.MinNationalityProbability} {
+		if !(p >= 0 && p <= 1) {
+			return fmt.Errorf("порог вероятности должен быть в диапазоне [0, 1]: %g", p)
+		}
+	}
+	validLowConfidenceModes := map[string]bool{LowConfidenceReject: true, LowConfidenceFlag: true}
+	if !validLowConfidenceModes[c.Enrichment.LowConfidenceMode] {
+		return fmt.Errorf("недопустимый режим обработки недостоверных значений: %s", c.Enrichment.LowConfidenceMode)
+	}
+	validCountryStrategies := map[string]bool{CountryStrategyNone: true, CountryStrategyHint: true, CountryStrategyTwoPass: true}
+	if !validCountryStrategies[c.Enrichment.CountryStrategy] {
+		return fmt.Errorf("недопустимая стратегия уточнения по стране: %s", c.Enrichment.CountryStrategy)
+	}
+	if len(c.Enrichment.Providers) == 0 {
+		return fmt.Errorf("не задан ни один источник обогащения")
+	}
+	validProviders := map[string]bool{ProviderLocal: true, ProviderRemote: true}
+	seenProviders := make(map[string]bool)
+	for _, provider := range c.Enrichment.Providers {
+		if !validProviders[provider] || seenProviders[provider] {
+			return fmt.Errorf("недопустимый источник обогащения: %s", provider)
+		}
+		seenProviders[provider] = true
+	}
+	for _, rawURL := range []string{c.Enrichment.AgifyURL, c.Enrichment.GenderizeURL, c.Enrichment.NationalizeURL} {
+		if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("недопустимый адрес внешнего API: %s", rawURL)
+		}
+	}
+	if c.Enrichment.QuotaLowWatermark < 0 || c.Enrichment.QuotaThrottleInterval <= 0 || c.Enrichment.QuotaResetWindow <= 0 {
+		return fmt.Errorf("недопустимые настройки квоты внешних API")
+	}
+	validQuotaModes := map[string]bool{QuotaLowThrottle: true, QuotaLowFallback: true}
+	if !validQuotaModes[c.Enrichment.QuotaLowMode] {
+		return fmt.Errorf("недопустимый режим при низком остатке квоты: %s", c.Enrichment.QuotaLowMode)
+	}
+
 	// Проверка окружения
 	validEnvs := map[string]bool{"development": true, "production": true, "test": true}
 	if !validEnvs[c.Env] {
@@ -142,16 +350,48 @@ func getEnv(key, defaultValue string) string {
 
 func getEnvAsInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
-		if i, err := fmt.Sscanf(value, "%d"); err == nil {
+		if i, err := strconv.Atoi(value); err == nil {
 			return i
 		}
 	}
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		return strings.ToLower(value) == "true"
 	}
 	return defaultValue
 }
+
+func getEnvAsList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
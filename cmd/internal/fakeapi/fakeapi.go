@@ -0,0 +1,178 @@
+package fakeapi
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"encoding/json"
+	"hash/fnv"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options задает поведение подменного сервера
+type Options struct {
+	Seed          uint64        // Одинаковый seed дает одинаковые ответы и одинаковую последовательность сбоев
+	ErrorRate     float64       // Доля ответов 500
+	RateLimitRate float64       // Доля ответов 429 с Retry-After
+	Latency       time.Duration // Задержка перед каждым ответом
+	Quota         int           // Число запросов до исчерпания квоты, 0 без ограничений
+}
+
+// countries страны, из которых выбираются национальности
+var countries = []string{"RU", "UA", "BY", "KZ", "US", "DE", "FR", "PL", "TR", "GE", "AM", "UZ"}
+
+// Server имитирует api.agify.io, api.genderize.io и api.nationalize.io по путям
+// /agify/, /genderize/ и /nationalize/. Ответы зависят только от seed, имени и страны,
+// поэтому повторяются между запусками; сбои выбираются генератором с тем же seed.
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+
+	mu        sync.Mutex
+	rnd       *rand.Rand
+	remaining int
+}
+
+// NewServer создает подменный сервер
+func NewServer(opts Options) *Server {
+	s := &Server{
+		opts:      opts,
+		mux:       http.NewServeMux(),
+		rnd:       rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15)),
+		remaining: opts.Quota,
+	}
+	s.mux.HandleFunc("/agify/", s.handle(s.age))
+	s.mux.HandleFunc("/genderize/", s.handle(s.gender))
+	s.mux.HandleFunc("/nationalize/", s.handle(s.nationality))
+	return s
+}
+
+// ServeHTTP реализует интерфейс http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handle оборачивает генератор ответа для одного имени: задержка, квота, сбои и пакетные запросы name[]
+func (s *Server) handle(answer func(name, countryID string) any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.opts.Latency > 0 {
+			select {
+			case <-time.After(s.opts.Latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		status, remaining := s.admit()
+		if s.opts.Quota > 0 {
+			w.Header().Set("X-Rate-Limit-Limit", strconv.Itoa(s.opts.Quota))
+			w.Header().Set("X-Rate-Limit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("X-Rate-Limit-Reset", "86400")
+		}
+		switch status {
+		case http.StatusTooManyRequests:
+			w.Header().Set("Retry-After", "1")
+			writeJSON(w, status, map[string]string{"error": "Request limit reached"})
+			return
+		case http.StatusInternalServerError:
+			writeJSON(w, status, map[string]string{"error": "Internal server error"})
+			return
+		}
+
+		query := r.URL.Query()
+		countryID := query.Get("country_id")
+		if names, ok := query["name[]"]; ok {
+			answers := make([]any, len(names))
+			for i, name := range names {
+				answers[i] = answer(name, countryID)
+			}
+			writeJSON(w, http.StatusOK, answers)
+			return
+		}
+		if !query.Has("name") {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "Missing 'name' parameter"})
+			return
+		}
+		writeJSON(w, http.StatusOK, answer(query.Get("name"), countryID))
+	}
+}
+
+// admit решает, как ответить на очередной запрос, и списывает квоту
+func (s *Server) admit() (status, remaining int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.opts.Quota > 0 {
+		if s.remaining <= 0 {
+			return http.StatusTooManyRequests, 0
+		}
+		s.remaining--
+	}
+	roll := s.rnd.Float64()
+	switch {
+	case roll < s.opts.ErrorRate:
+		return http.StatusInternalServerError, s.remaining
+	case roll < s.opts.ErrorRate+s.opts.RateLimitRate:
+		return http.StatusTooManyRequests, s.remaining
+	}
+	return http.StatusOK, s.remaining
+}
+
+// hash детерминированно смешивает seed с частями ключа
+func (s *Server) hash(parts ...string) uint64 {
+	h := fnv.New64a()
+	var seed [8]byte
+	for i := range seed {
+		seed[i] = byte(s.opts.Seed >> (8 * i))
+	}
+	h.Write(seed[:])
+	for _, part := range parts {
+		h.Write([]byte(strings.ToLower(part)))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+func (s *Server) age(name, countryID string) any {
+	h := s.hash("age", name, countryID)
+	return model.AgifyResponse{Name: name, Age: 18 + int(h%63), Count: 1 + int(h>>8%100000)}
+}
+
+func (s *Server) gender(name, countryID string) any {
+	h := s.hash("gender", name, countryID)
+	gender := "male"
+	if h%2 == 1 {
+		gender = "female"
+	}
+	probability := float64(50+h>>8%51) / 100
+	return model.GenderizeResponse{Name: name, Gender: gender, Probability: probability, Count: 1 + int(h>>16%100000)}
+}
+
+func (s *Server) nationality(name, _ string) any {
+	h := s.hash("nationality", name)
+	n := 1 + int(h%3)
+	start, step := int(h>>8%uint64(len(countries))), 1+int(h>>16%5)
+	result := model.NationalizeResponse{Name: name, Country: make([]model.CountryPrediction, 0, n)}
+	left := 1.0
+	for i := 0; i < n; i++ {
+		probability := float64(int(left*float64(40+h>>(24+4*i)%50))) / 100
+		left -= probability
+		result.Country = append(result.Country, model.CountryPrediction{
+			CountryID:   countries[(start+i*step)%len(countries)],
+			Probability: probability,
+		})
+	}
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		slog.Error("Ошибка кодирования JSON", "error", err)
+	}
+}
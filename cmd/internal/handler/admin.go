@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"TestEffectiveMobile/cmd/internal/service"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Интерфейс для служебных запросов
+type AdminHandler interface {
+	GetCacheStats(w http.ResponseWriter, r *http.Request)
+	GetQuota(w http.ResponseWriter, r *http.Request)
+	GetMetrics(w http.ResponseWriter, r *http.Request)
+	StartReenrichment(w http.ResponseWriter, r *http.Request)
+	GetReenrichment(w http.ResponseWriter, r *http.Request)
+}
+
+// Реализация обработчика служебных запросов
+type AdminHandlerImpl struct {
+	service service.AdminService
+}
+
+// Конструктор для создания обработчика служебных запросов
+func NewAdminHandler(service service.AdminService) *AdminHandlerImpl {
+	return &AdminHandlerImpl{service: service}
+}
+
+// requireAdminToken пропускает служебные запросы только с заголовком Authorization: Bearer <token>.
+// Пустой токен отключает служебные маршруты: запуск повторного обогащения меняет данные всех людей.
+func requireAdminToken(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				respondWithError(w, http.StatusForbidden, "Служебные маршруты отключены: не задан ADMIN_TOKEN")
+				return
+			}
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				respondWithError(w, http.StatusUnauthorized, "Требуется токен служебных маршрутов")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Статистика кеша обогащения
+// @Summary Статистика кеша обогащения
+// @Description Возвращает число попаданий и промахов кеша обогащения
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer <ADMIN_TOKEN>"
+// @Success 200 {object} service.CacheStats
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/enrichment/cache/ [get]
+func (h *AdminHandlerImpl) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.service.CacheStats())
+}
+
+// Остаток квот внешних API
+// @Summary Квоты внешних API
+// @Description Возвращает остаток квоты каждого провайдера по заголовкам X-Rate-Limit-* и число замедленных и отклоненных запросов
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer <ADMIN_TOKEN>"
+// @Success 200 {array} service.QuotaState
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/enrichment/quota/ [get]
+func (h *AdminHandlerImpl) GetQuota(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.service.QuotaStates())
+}
+
+// Метрики в текстовом формате Prometheus
+// @Summary Метрики
+// @Description Метрики кеша обогащения и квот внешних API в текстовом формате Prometheus
+// @Tags Admin
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics [get]
+func (h *AdminHandlerImpl) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	stats := h.service.CacheStats()
+	metric("enrichment_cache_hits_total", "counter", "Enrichment cache hits.")
+	fmt.Fprintf(&b, "enrichment_cache_hits_total %d\n", stats.Hits)
+	metric("enrichment_cache_misses_total", "counter", "Enrichment cache misses.")
+	fmt.Fprintf(&b, "enrichment_cache_misses_total %d\n", stats.Misses)
+
+	quotas := h.service.QuotaStates()
+	metric("enrichment_quota_remaining", "gauge", "Remaining provider quota reported by X-Rate-Limit-Remaining.")
+	for _, q := range quotas {
+		if q.Known {
+			fmt.Fprintf(&b, "enrichment_quota_remaining{provider=%q} %d\n", q.Provider, q.Remaining)
+		}
+	}
+	metric("enrichment_quota_limit", "gauge", "Provider quota limit reported by X-Rate-Limit-Limit.")
+	for _, q := range quotas {
+		if q.Known && q.Limit > 0 {
+			fmt.Fprintf(&b, "enrichment_quota_limit{provider=%q} %d\n", q.Provider, q.Limit)
+		}
+	}
+	metric("enrichment_quota_low", "gauge", "Whether the provider quota is at or below the low watermark.")
+	for _, q := range quotas {
+		low := 0
+		if q.Low {
+			low = 1
+		}
+		fmt.Fprintf(&b, "enrichment_quota_low{provider=%q} %d\n", q.Provider, low)
+	}
+	metric("enrichment_quota_throttled_total", "counter", "Requests delayed because of low quota.")
+	for _, q := range quotas {
+		fmt.Fprintf(&b, "enrichment_quota_throttled_total{provider=%q} %d\n", q.Provider, q.Throttled)
+	}
+	metric("enrichment_quota_rejected_total", "counter", "Requests not sent because of low or exhausted quota.")
+	for _, q := range quotas {
+		fmt.Fprintf(&b, "enrichment_quota_rejected_total{provider=%q} %d\n", q.Provider, q.Rejected)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := w.Write([]byte(b.String())); err != nil {
+		slog.Error("Ошибка записи метрик", "error", err)
+	}
+}
+
+// Запуск повторного обогащения
+// @Summary Повторное обогащение
+// @Description Запускает в фоне повторное обогащение сохраненных людей по фильтру. Значения, заданные клиентом или оператором, не перезаписываются. В режиме dry_run изменения только перечисляются.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param options body service.ReenrichOptions true "Фильтр и режим запуска"
+// @Param Authorization header string true "Bearer <ADMIN_TOKEN>"
+// @Success 202 {object} service.ReenrichRun
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/enrichment/reenrich/ [post]
+func (h *AdminHandlerImpl) StartReenrichment(w http.ResponseWriter, r *http.Request) {
+	var opts service.ReenrichOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Некорректный формат данных")
+		return
+	}
+
+	run, err := h.service.StartReenrichment(opts)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, run)
+}
+
+// Состояние повторного обогащения
+// @Summary Состояние повторного обогащения
+// @Description Возвращает статус и итог (промежуточный, пока запуск выполняется) повторного обогащения
+// @Tags Admin
+// @Produce json
+// @Param id path int true "ID запуска"
+// @Param Authorization header string true "Bearer <ADMIN_TOKEN>"
+// @Success 200 {object} service.ReenrichRun
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/enrichment/reenrich/{id}/ [get]
+func (h *AdminHandlerImpl) GetReenrichment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Некорректный ID")
+		return
+	}
+
+	run, ok := h.service.GetReenrichment(id)
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Запуск повторного обогащения не найден")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, run)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAdminToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "токен не задан", authorization: "Bearer secret", wantStatus: http.StatusForbidden},
+		{name: "без заголовка", token: "secret", wantStatus: http.StatusUnauthorized},
+		{name: "неверный токен", token: "secret", authorization: "Bearer wrong", wantStatus: http.StatusUnauthorized},
+		{name: "другая схема", token: "secret", authorization: "Basic secret", wantStatus: http.StatusUnauthorized},
+		{name: "верный токен", token: "secret", authorization: "Bearer secret", wantStatus: http.StatusOK},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/admin/enrichment/cache/", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			requireAdminToken(tt.token)(ok).ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if challenge := w.Header().Get("WWW-Authenticate"); (challenge != "") != (tt.wantStatus == http.StatusUnauthorized) {
+				t.Errorf("WWW-Authenticate = %q при статусе %d", challenge, w.Code)
+			}
+		})
+	}
+}
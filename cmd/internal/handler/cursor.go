@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// cursor содержимое непрозрачного курсора: сортировка, для которой он получен,
+// и значения ее полей у последней строки страницы
+type cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// integerSortFields поля сортировки со значениями INTEGER; остальные сравниваются как строки
+var integerSortFields = []string{"id", "age"}
+
+// sortSpec записывает сортировку в виде параметра sort, например surname,-age,id
+func sortSpec(sort []model.SortField) string {
+	fields := make([]string, len(sort))
+	for i, field := range sort {
+		fields[i] = field.Field
+		if field.Desc {
+			fields[i] = "-" + field.Field
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// encodeCursor кодирует позицию в списке для параметра after
+func encodeCursor(sort []model.SortField, values []string) string {
+	data, _ := json.Marshal(cursor{Sort: sortSpec(model.StableSort(sort)), Values: values})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor возвращает значения полей сортировки из курсора; курсор действителен только для той же сортировки
+func decodeCursor(value string, sort []model.SortField) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("курсор поврежден")
+	}
+	var c cursor
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, errors.New("курсор поврежден")
+	}
+	sort = model.StableSort(sort)
+	if c.Sort != sortSpec(sort) || len(c.Values) != len(sort) {
+		return nil, errors.New("курсор получен для другой сортировки")
+	}
+	// Значение неверного типа дошло бы до БД и вернулось ошибкой данных
+	for i, field := range sort {
+		if _, err = strconv.ParseInt(c.Values[i], 10, 32); err != nil && slices.Contains(integerSortFields, field.Field) {
+			return nil, errors.New("курсор поврежден")
+		}
+	}
+	return c.Values, nil
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		sort   []model.SortField
+		values []string
+	}{
+		{name: "по умолчанию", values: []string{"42"}},
+		{
+			name:   "смешанные направления",
+			sort:   []model.SortField{{Field: "surname"}, {Field: "age", Desc: true}},
+			values: []string{"Иванов", "30", "7"},
+		},
+		{
+			name:   "строка с разделителями",
+			sort:   []model.SortField{{Field: "name", Desc: true}, {Field: "id", Desc: true}},
+			values: []string{`O'Brien, "Jr"/+=`, "10"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(encodeCursor(tt.sort, tt.values), tt.sort)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.values) {
+				t.Errorf("decodeCursor() = %q, want %q", got, tt.values)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	bySurname := []model.SortField{{Field: "surname"}}
+	raw := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+	tests := []struct {
+		name   string
+		cursor string
+		sort   []model.SortField
+	}{
+		{name: "не base64", cursor: "!!!", sort: bySurname},
+		{name: "base64 не JSON", cursor: raw("surname=Ivanov"), sort: bySurname},
+		{name: "измененный base64", cursor: encodeCursor(bySurname, []string{"Ivanov", "7"})[1:], sort: bySurname},
+		{name: "другая сортировка", cursor: encodeCursor(bySurname, []string{"Ivanov", "7"}), sort: []model.SortField{{Field: "surname", Desc: true}}},
+		{name: "другое поле", cursor: encodeCursor(bySurname, []string{"Ivanov", "7"}), sort: []model.SortField{{Field: "name"}}},
+		{name: "лишнее значение", cursor: raw(`{"s":"surname,id","v":["Ivanov","7","8"]}`), sort: bySurname},
+		{name: "id не число", cursor: raw(`{"s":"surname,id","v":["Ivanov","7 OR 1=1"]}`), sort: bySurname},
+		{name: "возраст не число", cursor: raw(`{"s":"age,id","v":["тридцать","7"]}`), sort: []model.SortField{{Field: "age"}}},
+		{name: "id вне INTEGER", cursor: raw(`{"s":"id","v":["9999999999"]}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if values, err := decodeCursor(tt.cursor, tt.sort); err == nil {
+				t.Errorf("decodeCursor() = %q, want error", values)
+			}
+		})
+	}
+}
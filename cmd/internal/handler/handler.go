@@ -5,18 +5,28 @@ import (
 	"TestEffectiveMobile/cmd/internal/service"
 	_ "TestEffectiveMobile/docs"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
-	"log/slog"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 )
 
 // Интерфейс для обработки запросов с людьми
 type PersonHandler interface {
+	GetPerson(w http.ResponseWriter, r *http.Request)
 	GetPersons(w http.ResponseWriter, r *http.Request)
 	AddPerson(w http.ResponseWriter, r *http.Request)
+	AddPersons(w http.ResponseWriter, r *http.Request)
 	UpdatePerson(w http.ResponseWriter, r *http.Request)
+	PatchPerson(w http.ResponseWriter, r *http.Request)
 	DeletePerson(w http.ResponseWriter, r *http.Request)
+	GetPersonJobs(w http.ResponseWriter, r *http.Request)
 }
 
 // Реализация обработчика для людей
@@ -29,50 +39,309 @@ func NewPersonHandler(service service.PersonService) *PersonHandlerImpl {
 	return &PersonHandlerImpl{service: service}
 }
 
-// Структура стандартного ответа об ошибке
-type ErrorResponse struct {
-	Detail string `json:"detail"`
-}
+// Получение человека по ID
+// @Summary Получить человека
+// @Description Возвращает человека по ID. ETag ответа — версия человека; при совпадении с If-None-Match возвращается 304 без тела.
+// @Tags Person
+// @Produce json
+// @Param id path int true "ID человека"
+// @Param If-None-Match header string false "ETag ранее полученного ответа"
+// @Success 200 {object} model.Person
+// @Success 304 "Не изменился"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /persons/{id}/ [get]
+func (h *PersonHandlerImpl) GetPerson(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Некорректный ID")
+		return
+	}
 
-// Структура стандартного ответа об успехе
-type SuccessResponse struct {
-	Message string `json:"message"`
+	person, err := h.service.GetPerson(id)
+	if err != nil {
+		respondWithServiceError(w, err, "Не удалось получить человека")
+		return
+	}
+
+	respondWithETag(w, r, personETag(person.Version), person)
 }
 
 // Получение всех людей с пагинацией и фильтрами
 // @Summary Получить список людей
-// @Description Получение всех людей с пагинацией и фильтрами
+// @Description Получение всех людей с пагинацией и фильтрами. Страница выбирается номером (page) или курсором (after).
+// @Description С after ответ — объект PersonsPageResponse с items и next_cursor; пустой after начинает обход с первой страницы.
+// @Description Без after возвращается массив, а курсор следующей страницы передается в заголовке X-Next-Cursor;
+// @Description с envelope=true вместо массива возвращается PersonsPageResponse. Пустой список — всегда [].
+// @Description Ссылки на соседние страницы передаются в Link (RFC 8288), общее число людей с total=exact или estimated — в X-Total-Count.
 // @Tags Person
 // @Accept json
 // @Produce json
 // @Param page query int false "Номер страницы" default(1)
-// @Param limit query int false "Количество на странице" default(10)
+// @Param limit query int false "Количество на странице, не больше 100" default(10)
+// @Param after query string false "Курсор из next_cursor предыдущей страницы"
+// @Param envelope query bool false "Вернуть объект с items, page, limit, total, next и prev вместо массива"
+// @Param total query string false "Подсчет общего числа: не считать, точно или оценкой по статистике БД" Enums(none, exact, estimated) default(none)
 // @Param name query string false "Фильтр по имени"
-// @Param gender query string false "Фильтр по полу"
-// @Param nationality query string false "Фильтр по национальности"
+// @Param surname query string false "Фильтр по подстроке фамилии"
+// @Param patronymic query string false "Фильтр по подстроке отчества"
+// @Param no_patronymic query bool false "Только люди без отчества"
+// @Param gender query string false "Фильтр по полу" Enums(male, female)
+// @Param gender! query string false "Пол не равен указанному (gender!=male); люди с неизвестным полом подходят" Enums(male, female)
+// @Param nationality query string false "Фильтр по национальности: один или несколько кодов страны через запятую (RU,UA,KZ)"
+// @Param age_min query int false "Минимальный возраст"
+// @Param age_max query int false "Максимальный возраст"
+// @Param enrichment_status query string false "Фильтр по статусу обогащения" Enums(complete, pending, failed)
+// @Param min_gender_probability query number false "Минимальная вероятность пола"
+// @Param min_nationality_probability query number false "Минимальная вероятность национальности"
+// @Param sort query string false "Сортировка через запятую, минус — по убыванию: surname,-age. Поля: id, name, surname, patronymic, age, gender, nationality"
 // @Success 200 {array} model.Person
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
+// @Header 200 {integer} X-Total-Count "Общее число людей, подходящих под фильтр (total=exact или estimated)"
+// @Header 200 {string} Link "Ссылки first, prev, next и last"
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /persons [get]
 func (h *PersonHandlerImpl) GetPersons(w http.ResponseWriter, r *http.Request) {
-	page, err := strconv.Atoi(r.URL.Query().Get("page"))
-	if err != nil || page <= 0 {
-		page = 1
+	query := r.URL.Query()
+	var page model.PageRequest
+	var err error
+	if page.Page, err = strconv.Atoi(query.Get("page")); err != nil || page.Page <= 0 {
+		page.Page = 1
+	}
+	if page.Limit, err = strconv.Atoi(query.Get("limit")); err != nil || page.Limit <= 0 {
+		page.Limit = 10
+	}
+	filter, err := parsePersonFilter(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Некорректный фильтр: "+err.Error())
+		return
+	}
+	if page.Sort, err = parseSort(query.Get("sort")); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Некорректная сортировка: "+err.Error())
+		return
 	}
-	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
-	if err != nil || limit <= 0 {
-		limit = 10
+	envelope := false
+	if value := query.Get("envelope"); value != "" {
+		if envelope, err = strconv.ParseBool(value); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Некорректное значение envelope")
+			return
+		}
+	}
+	switch page.Total = query.Get("total"); page.Total {
+	case "", model.TotalNone, model.TotalExact, model.TotalEstimated:
+	default:
+		respondWithError(w, http.StatusBadRequest, "Некорректное значение total: допустимы none, exact и estimated")
+		return
+	}
+	byCursor := query.Has("after")
+	if after := query.Get("after"); after != "" {
+		if page.After, err = decodeCursor(after, page.Sort); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Некорректный курсор: "+err.Error())
+			return
+		}
 	}
-	name := r.URL.Query().Get("name")
-	gender := r.URL.Query().Get("gender")
-	nationality := r.URL.Query().Get("nationality")
 
-	persons, err := h.service.GetPersons(page, limit, name, gender, nationality)
+	result, err := h.service.GetPersons(filter, page)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Не удалось получить список людей")
+		respondWithServiceError(w, err, "Не удалось получить список людей")
 		return
 	}
 
-	h.respondWithJSON(w, http.StatusOK, persons)
+	var next string
+	if result.Next != nil {
+		next = encodeCursor(page.Sort, result.Next)
+	}
+	response := PersonsPageResponse{Items: result.Items, Limit: result.Limit, TotalEstimated: result.TotalEstimated}
+	if result.TotalKnown {
+		response.Total = &result.Total
+		w.Header().Set("X-Total-Count", strconv.Itoa(result.Total))
+	}
+	var links []pageLink
+	if byCursor {
+		links = cursorLinks(r, result, next)
+		response.NextCursor = next
+	} else {
+		links = pageLinks(r, result)
+		response.Page = result.Page
+		response.Prev = linkURL(links, "prev")
+		if next != "" {
+			w.Header().Set("X-Next-Cursor", next)
+		}
+	}
+	response.Next = linkURL(links, "next")
+	w.Header().Set("Link", linkHeader(links))
+
+	if byCursor || envelope {
+		respondWithJSON(w, http.StatusOK, response)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, result.Items)
+}
+
+// Разбор фильтров списка людей из параметров запроса; ошибка описывает некорректный параметр
+func parsePersonFilter(query url.Values) (model.PersonFilter, error) {
+	filter := model.PersonFilter{
+		Name:             query.Get("name"),
+		Surname:          query.Get("surname"),
+		Patronymic:       query.Get("patronymic"),
+		Gender:           query.Get("gender"),
+		ExcludeGender:    query.Get("gender!"),
+		EnrichmentStatus: query.Get("enrichment_status"),
+	}
+	var err error
+
+	if !validGender(filter.Gender) || !validGender(filter.ExcludeGender) {
+		return filter, errors.New("некорректный пол: допустимы male и female")
+	}
+	if value := query.Get("no_patronymic"); value != "" {
+		if filter.NoPatronymic, err = strconv.ParseBool(value); err != nil {
+			return filter, errors.New("некорректное значение no_patronymic")
+		}
+	}
+	if filter.NoPatronymic && filter.Patronymic != "" {
+		return filter, errors.New("фильтры patronymic и no_patronymic несовместимы")
+	}
+	if value := query.Get("nationality"); value != "" {
+		for _, code := range strings.Split(value, ",") {
+			code = strings.ToUpper(strings.TrimSpace(code))
+			if len(code) != 2 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+				return filter, fmt.Errorf("некорректный код страны: %q", code)
+			}
+			filter.Nationalities = append(filter.Nationalities, code)
+		}
+	}
+	if filter.AgeMin, err = parseAge(query.Get("age_min")); err != nil {
+		return filter, errors.New("некорректный минимальный возраст")
+	}
+	if filter.AgeMax, err = parseAge(query.Get("age_max")); err != nil {
+		return filter, errors.New("некорректный максимальный возраст")
+	}
+	if filter.AgeMax > 0 && filter.AgeMin > filter.AgeMax {
+		return filter, errors.New("минимальный возраст больше максимального")
+	}
+	if !validEnrichmentStatus(filter.EnrichmentStatus) {
+		return filter, errors.New("некорректный статус обогащения")
+	}
+	if filter.MinGenderProbability, err = parseProbability(query.Get("min_gender_probability")); err != nil {
+		return filter, errors.New("некорректная минимальная вероятность пола")
+	}
+	if filter.MinNationalityProbability, err = parseProbability(query.Get("min_nationality_probability")); err != nil {
+		return filter, errors.New("некорректная минимальная вероятность национальности")
+	}
+	return filter, nil
+}
+
+// Разбор сортировки вида surname,-age,id: минус перед полем означает сортировку по убыванию
+func parseSort(value string) ([]model.SortField, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var sort []model.SortField
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		field := model.SortField{Field: strings.TrimSpace(item)}
+		if name, ok := strings.CutPrefix(field.Field, "-"); ok {
+			field.Field, field.Desc = name, true
+		}
+		if !slices.Contains(model.PersonSortFields, field.Field) {
+			return nil, fmt.Errorf("неизвестное поле %q", field.Field)
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("поле %q указано дважды", field.Field)
+		}
+		seen[field.Field] = true
+		sort = append(sort, field)
+	}
+	return sort, nil
+}
+
+// Проверка значения фильтра по статусу обогащения
+func validEnrichmentStatus(status string) bool {
+	switch status {
+	case "", model.EnrichmentComplete, model.EnrichmentPending, model.EnrichmentFailed:
+		return true
+	}
+	return false
+}
+
+// Проверка значения фильтра по полу
+func validGender(gender string) bool {
+	return gender == "" || gender == "male" || gender == "female"
+}
+
+// Разбор возраста из параметра запроса; пустое значение означает отсутствие фильтра
+func parseAge(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	age, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if age < 0 || age > service.MaxAge {
+		return 0, fmt.Errorf("возраст вне диапазона [0, %d]: %d", service.MaxAge, age)
+	}
+	return age, nil
+}
+
+// Разбор вероятности из параметра запроса; пустое значение означает отсутствие фильтра
+func parseProbability(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	p, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if !(p >= 0 && p <= 1) {
+		return 0, fmt.Errorf("вероятность вне диапазона [0, 1]: %g", p)
+	}
+	return p, nil
+}
+
+// Разбор подсказки страны: код ISO 3166-1 alpha-2 (RU) или локаль (ru-RU, ru_RU, zh-Hant-TW).
+// Страна берется только из региона локали. Тег языка без региона (ru, uk, en) о стране
+// не говорит, поэтому такая подсказка игнорируется: uk — украинский язык, а не страна UK.
+func parseCountryHint(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	invalid := fmt.Errorf("некорректный код страны: %s", value)
+	subtags := strings.FieldsFunc(value, func(r rune) bool { return r == '-' || r == '_' })
+	if len(subtags) == 0 || !isLetters(subtags[0]) {
+		return "", invalid
+	}
+	if len(subtags) == 1 {
+		switch {
+		case len(value) == 2 && value == strings.ToUpper(value):
+			return value, nil
+		case len(value) <= 3 && value == strings.ToLower(value):
+			return "", nil
+		}
+		return "", invalid
+	}
+	if len(subtags[0]) < 2 || len(subtags[0]) > 3 {
+		return "", invalid
+	}
+	for _, subtag := range subtags[1:] {
+		switch {
+		case len(subtag) == 4 && isLetters(subtag):
+			// Письменность (Hant) стоит перед регионом
+			continue
+		case len(subtag) == 2 && isLetters(subtag):
+			return strings.ToUpper(subtag), nil
+		}
+		// Числовой регион (419) и варианты не соответствуют одной стране
+		return "", nil
+	}
+	return "", nil
+}
+
+// isLetters сообщает, состоит ли строка только из латинских букв
+func isLetters(value string) bool {
+	return value != "" && strings.Trim(strings.ToUpper(value), "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == ""
 }
 
 // Добавление нового человека
@@ -82,97 +351,305 @@ func (h *PersonHandlerImpl) GetPersons(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param person body model.Person true "Данные нового человека"
-// @Success 201 {object} SuccessResponse
+// @Param no_cache query bool false "Не использовать кеш обогащения"
+// @Param country_id query string false "Страна (RU) или локаль (ru-RU) для уточнения возраста и пола"
+// @Success 201 {object} AddPersonResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Failure 504 {object} ErrorResponse
 // @Router /person [post]
 func (h *PersonHandlerImpl) AddPerson(w http.ResponseWriter, r *http.Request) {
 	var person model.Person
 	if err := json.NewDecoder(r.Body).Decode(&person); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Некорректный формат данных")
+		respondWithError(w, http.StatusBadRequest, "Некорректный формат данных")
+		return
+	}
+
+	countryHint, err := parseCountryHint(r.URL.Query().Get("country_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Некорректная страна")
 		return
 	}
 
-	err := h.service.AddPerson(person)
+	ctx := r.Context()
+	if noCache, _ := strconv.ParseBool(r.URL.Query().Get("no_cache")); noCache {
+		ctx = service.WithCacheBypass(ctx)
+	}
+
+	report, err := h.service.AddPerson(ctx, person, countryHint)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Не удалось добавить человека")
+		respondWithServiceError(w, err, "Не удалось добавить человека")
 		return
 	}
 
-	h.respondWithJSON(w, http.StatusCreated, SuccessResponse{Message: "Человек успешно добавлен"})
+	respondWithJSON(w, http.StatusCreated, AddPersonResponse{Message: "Человек успешно добавлен", Enrichment: report})
+}
+
+// Ограничения запроса массовой загрузки: число людей и размер тела в байтах
+const (
+	maxBulkPersons   = 1000
+	maxBulkBodyBytes = 1 << 20
+)
+
+// Массовое добавление людей
+// @Summary Массово добавить людей
+// @Description Добавляет людей в БД, обогащая их пачками: одинаковые имена запрашиваются во внешних API один раз
+// @Tags Person
+// @Accept json
+// @Produce json
+// @Param persons body []model.Person true "Данные новых людей"
+// @Param no_cache query bool false "Не использовать кеш обогащения"
+// @Param country_id query string false "Страна (RU) или локаль (ru-RU) для уточнения возраста и пола"
+// @Success 200 {object} BulkAddPersonsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Router /persons/bulk/ [post]
+func (h *PersonHandlerImpl) AddPersons(w http.ResponseWriter, r *http.Request) {
+	var persons []model.Person
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&persons); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Размер запроса превышает %d байт", maxBulkBodyBytes))
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Некорректный формат данных")
+		return
+	}
+	if len(persons) == 0 || len(persons) > maxBulkPersons {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Количество людей должно быть от 1 до %d", maxBulkPersons))
+		return
+	}
+
+	countryHint, err := parseCountryHint(r.URL.Query().Get("country_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Некорректная страна")
+		return
+	}
+
+	ctx := r.Context()
+	if noCache, _ := strconv.ParseBool(r.URL.Query().Get("no_cache")); noCache {
+		ctx = service.WithCacheBypass(ctx)
+	}
+
+	response := BulkAddPersonsResponse{Items: make([]BulkAddPersonItem, 0, len(persons))}
+	for i, result := range h.service.AddPersons(ctx, persons, countryHint) {
+		item := BulkAddPersonItem{Index: i, Status: http.StatusCreated}
+		if result.Err != nil {
+			item.Status, item.Error = errorStatus(result.Err, "Не удалось добавить человека")
+			response.Failed++
+		} else {
+			item.Enrichment = &result.Report
+			response.Created++
+		}
+		response.Items = append(response.Items, item)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
 }
 
 // Обновление данных человека
 // @Summary Обновить человека
-// @Description Обновляет данные человека по ID
+// @Description Обновляет данные человека по ID. С If-Match обновление выполняется, только если версия человека не изменилась.
 // @Tags Person
 // @Accept json
 // @Produce json
 // @Param id path int true "ID человека"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет, или список ETag через запятую"
 // @Param person body model.Person true "Обновленные данные"
 // @Success 200 {object} SuccessResponse
+// @Header 200 {string} ETag "Новая версия человека"
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /person/{id} [put]
 func (h *PersonHandlerImpl) UpdatePerson(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Некорректный ID")
+		respondWithError(w, http.StatusBadRequest, "Некорректный ID")
+		return
+	}
+
+	version, err := h.ifMatchVersion(r, id)
+	if errors.Is(err, errInvalidIfMatch) {
+		respondWithError(w, http.StatusBadRequest, "Некорректный If-Match")
+		return
+	}
+	if err != nil {
+		respondWithServiceError(w, err, "Не удалось проверить If-Match")
 		return
 	}
 
 	var person model.Person
 	if err := json.NewDecoder(r.Body).Decode(&person); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Некорректный формат данных")
+		respondWithError(w, http.StatusBadRequest, "Некорректный формат данных")
+		return
+	}
+
+	version, err = h.service.UpdatePerson(id, version, person)
+	if err != nil {
+		respondWithServiceError(w, err, "Не удалось обновить данные")
+		return
+	}
+
+	w.Header().Set("ETag", personETag(version))
+	respondWithJSON(w, http.StatusOK, SuccessResponse{Message: "Данные успешно обновлены"})
+}
+
+// Типы содержимого частичного обновления
+const (
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+)
+
+// Частичное обновление данных человека
+// @Summary Частично обновить человека
+// @Description Изменяет только переданные поля: name, surname, patronymic, age, gender, nationality.
+// @Description application/merge-patch+json (RFC 7396, по умолчанию): null удаляет значение.
+// @Description application/json-patch+json (RFC 6902): операции с путями верхнего уровня, например /surname.
+// @Description Измененные возраст, пол и национальность считаются исправленными оператором.
+// @Description С If-Match патч применяется, только если версия человека не изменилась.
+// @Tags Person
+// @Accept json
+// @Produce json
+// @Param id path int true "ID человека"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет, или список ETag через запятую"
+// @Param patch body object true "Merge patch или массив операций JSON Patch"
+// @Param reenrich query bool false "Заново обогатить человека, если изменилось имя"
+// @Success 200 {object} model.Person
+// @Header 200 {string} ETag "Новая версия человека"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 504 {object} ErrorResponse
+// @Router /persons/{id}/ [patch]
+func (h *PersonHandlerImpl) PatchPerson(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Некорректный ID")
+		return
+	}
+
+	version, err := h.ifMatchVersion(r, id)
+	if errors.Is(err, errInvalidIfMatch) {
+		respondWithError(w, http.StatusBadRequest, "Некорректный If-Match")
+		return
+	}
+	if err != nil {
+		respondWithServiceError(w, err, "Не удалось проверить If-Match")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Некорректный формат данных")
+		return
+	}
+
+	var patch service.PersonPatch
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case contentTypeMergePatch, "application/json", "":
+		patch, err = service.NewMergePatch(body)
+	case contentTypeJSONPatch:
+		patch, err = service.NewJSONPatch(body)
+	default:
+		respondWithError(w, http.StatusUnsupportedMediaType,
+			fmt.Sprintf("Поддерживаются %s и %s", contentTypeMergePatch, contentTypeJSONPatch))
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Некорректный формат данных: %v", err))
 		return
 	}
 
-	err = h.service.UpdatePerson(id, person)
+	reenrich, _ := strconv.ParseBool(r.URL.Query().Get("reenrich"))
+	person, err := h.service.PatchPerson(r.Context(), id, version, patch, reenrich)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Не удалось обновить данные")
+		respondWithServiceError(w, err, "Не удалось обновить данные")
 		return
 	}
 
-	h.respondWithJSON(w, http.StatusOK, SuccessResponse{Message: "Данные успешно обновлены"})
+	w.Header().Set("ETag", personETag(person.Version))
+	respondWithJSON(w, http.StatusOK, person)
 }
 
 // Удаление человека
 // @Summary Удалить человека
-// @Description Удаляет человека по ID
+// @Description Удаляет человека по ID. С If-Match человек удаляется, только если его версия не изменилась.
 // @Tags Person
 // @Accept json
 // @Produce json
 // @Param id path int true "ID человека"
+// @Param If-Match header string false "ETag версии, которую клиент удаляет, или список ETag через запятую"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /person/{id} [delete]
 func (h *PersonHandlerImpl) DeletePerson(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Некорректный ID")
+		respondWithError(w, http.StatusBadRequest, "Некорректный ID")
+		return
+	}
+
+	version, err := h.ifMatchVersion(r, id)
+	if errors.Is(err, errInvalidIfMatch) {
+		respondWithError(w, http.StatusBadRequest, "Некорректный If-Match")
+		return
+	}
+	if err != nil {
+		respondWithServiceError(w, err, "Не удалось проверить If-Match")
 		return
 	}
 
-	err = h.service.DeletePerson(id)
+	err = h.service.DeletePerson(id, version)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Не удалось удалить человека")
+		respondWithServiceError(w, err, "Не удалось удалить человека")
 		return
 	}
 
-	h.respondWithJSON(w, http.StatusOK, SuccessResponse{Message: "Человек успешно удалён"})
+	respondWithJSON(w, http.StatusOK, SuccessResponse{Message: "Человек успешно удалён"})
 }
 
-// Универсальный метод для ответа с JSON и статусом
-func (h *PersonHandlerImpl) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		slog.Error("Ошибка кодирования JSON: ", err)
+// Получение заданий на обогащение человека
+// @Summary Задания на обогащение
+// @Description Возвращает историю заданий фонового обогащения человека
+// @Tags Person
+// @Produce json
+// @Param id path int true "ID человека"
+// @Success 200 {array} model.EnrichmentJob
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /persons/{id}/jobs/ [get]
+func (h *PersonHandlerImpl) GetPersonJobs(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Некорректный ID")
+		return
+	}
+
+	jobs, err := h.service.GetEnrichmentJobs(id)
+	if err != nil {
+		respondWithServiceError(w, err, "Не удалось получить задания на обогащение")
+		return
 	}
-}
 
-// Универсальный метод для ответа с ошибкой
-func (h *PersonHandlerImpl) respondWithError(w http.ResponseWriter, code int, message string) {
-	h.respondWithJSON(w, code, ErrorResponse{Detail: message})
+	respondWithJSON(w, http.StatusOK, jobs)
 }
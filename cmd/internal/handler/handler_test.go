@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseCountryHint(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+		wantErr        bool
+	}{
+		{name: "пусто", in: ""},
+		{name: "код страны", in: "RU", want: "RU"},
+		{name: "локаль через дефис", in: "uk-UA", want: "UA"},
+		{name: "локаль через подчеркивание", in: "ru_RU", want: "RU"},
+		{name: "регион в нижнем регистре", in: "en-gb", want: "GB"},
+		{name: "письменность перед регионом", in: "zh-Hant-TW", want: "TW"},
+		{name: "язык uk не страна UK", in: "uk"},
+		{name: "язык en не страна EN", in: "en"},
+		{name: "трехбуквенный язык", in: "fil"},
+		{name: "числовой регион", in: "es-419"},
+		{name: "только письменность", in: "sr-Latn"},
+		{name: "смешанный регистр", in: "Ru", wantErr: true},
+		{name: "цифры", in: "12", wantErr: true},
+		{name: "длинное значение", in: "RUS", wantErr: true},
+		{name: "некорректный язык", in: "r-RU", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCountryHint(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCountryHint(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseCountryHint(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddPersonsRejectsInvalidBody(t *testing.T) {
+	huge := "[" + strings.Repeat(`{"name": "Ivan", "surname": "Ivanov"},`, maxBulkBodyBytes/30) + `{"name": "Ivan"}]`
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "тело больше ограничения", body: huge, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "не JSON", body: `[{"name":`, wantStatus: http.StatusBadRequest},
+		{name: "пустой массив", body: `[]`, wantStatus: http.StatusBadRequest},
+	}
+	// Сервис не должен вызываться: запрос отклоняется до обогащения
+	h := NewPersonHandler(&personStub{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.AddPersons(w, httptest.NewRequest("POST", "/persons/bulk/", strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// pageLink ссылка на соседнюю страницу списка для заголовка Link (RFC 8288)
+type pageLink struct {
+	Rel string
+	URL string
+}
+
+// pageURL возвращает адрес текущего запроса, в котором параметры set заменены, а параметры remove удалены
+func pageURL(r *http.Request, set map[string]string, remove ...string) string {
+	query := r.URL.Query()
+	for _, key := range remove {
+		query.Del(key)
+	}
+	for key, value := range set {
+		query.Set(key, value)
+	}
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// pageLinks возвращает ссылки first, prev, next и last для страницы, выбранной номером.
+// Ссылка last возвращается, только если общее число людей подсчитано точно.
+func pageLinks(r *http.Request, page model.PersonPage) []pageLink {
+	at := func(n int) string {
+		return pageURL(r, map[string]string{"page": strconv.Itoa(n), "limit": strconv.Itoa(page.Limit)}, "after")
+	}
+	links := []pageLink{{Rel: "first", URL: at(1)}}
+	if page.Page > 1 {
+		links = append(links, pageLink{Rel: "prev", URL: at(page.Page - 1)})
+	}
+	if page.Next != nil {
+		links = append(links, pageLink{Rel: "next", URL: at(page.Page + 1)})
+	}
+	if page.TotalKnown && !page.TotalEstimated {
+		last := max(1, (page.Total+page.Limit-1)/page.Limit)
+		links = append(links, pageLink{Rel: "last", URL: at(last)})
+	}
+	return links
+}
+
+// cursorLinks возвращает ссылки first и next для страницы, выбранной курсором.
+// Курсор ведет только вперед, поэтому prev и last не возвращаются.
+func cursorLinks(r *http.Request, page model.PersonPage, next string) []pageLink {
+	at := func(after string) string {
+		return pageURL(r, map[string]string{"after": after, "limit": strconv.Itoa(page.Limit)}, "page")
+	}
+	links := []pageLink{{Rel: "first", URL: at("")}}
+	if next != "" {
+		links = append(links, pageLink{Rel: "next", URL: at(next)})
+	}
+	return links
+}
+
+// linkHeader записывает ссылки в формате заголовка Link: <url>; rel="next", ...
+func linkHeader(links []pageLink) string {
+	parts := make([]string, len(links))
+	for i, link := range links {
+		parts[i] = "<" + link.URL + `>; rel="` + link.Rel + `"`
+	}
+	return strings.Join(parts, ", ")
+}
+
+// linkURL возвращает адрес ссылки rel или пустую строку
+func linkURL(links []pageLink, rel string) string {
+	for _, link := range links {
+		if link.Rel == rel {
+			return link.URL
+		}
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"TestEffectiveMobile/cmd/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// pageStub сервис со списком из 45 людей, который считает их, только если подсчет запрошен
+type pageStub struct {
+	service.PersonService
+}
+
+func (s *pageStub) GetPersons(filter model.PersonFilter, page model.PageRequest) (model.PersonPage, error) {
+	result := model.PersonPage{Items: []model.Person{{ID: 1}}, Page: max(page.Page, 1), Limit: 10, Next: []string{"1"}}
+	if page.Total == model.TotalExact || page.Total == model.TotalEstimated {
+		result.Total, result.TotalKnown, result.TotalEstimated = 45, true, page.Total == model.TotalEstimated
+	}
+	return result, nil
+}
+
+func TestPageLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		page model.PersonPage
+		want map[string]string
+	}{
+		{
+			name: "первая страница",
+			page: model.PersonPage{Page: 1, Limit: 10, Next: []string{"10"}, Total: 45, TotalKnown: true},
+			want: map[string]string{
+				"first": "/persons?limit=10&page=1&sort=-age",
+				"next":  "/persons?limit=10&page=2&sort=-age",
+				"last":  "/persons?limit=10&page=5&sort=-age",
+			},
+		},
+		{
+			name: "средняя страница",
+			page: model.PersonPage{Page: 3, Limit: 10, Next: []string{"30"}, Total: 45, TotalKnown: true},
+			want: map[string]string{
+				"first": "/persons?limit=10&page=1&sort=-age",
+				"prev":  "/persons?limit=10&page=2&sort=-age",
+				"next":  "/persons?limit=10&page=4&sort=-age",
+				"last":  "/persons?limit=10&page=5&sort=-age",
+			},
+		},
+		{
+			name: "последняя страница",
+			page: model.PersonPage{Page: 5, Limit: 10, Total: 45, TotalKnown: true},
+			want: map[string]string{
+				"first": "/persons?limit=10&page=1&sort=-age",
+				"prev":  "/persons?limit=10&page=4&sort=-age",
+				"last":  "/persons?limit=10&page=5&sort=-age",
+			},
+		},
+		{
+			name: "пустой список",
+			page: model.PersonPage{Page: 1, Limit: 10, TotalKnown: true},
+			want: map[string]string{
+				"first": "/persons?limit=10&page=1&sort=-age",
+				"last":  "/persons?limit=10&page=1&sort=-age",
+			},
+		},
+		{
+			name: "без last при оценке",
+			page: model.PersonPage{Page: 2, Limit: 10, Next: []string{"20"}, Total: 45, TotalKnown: true, TotalEstimated: true},
+			want: map[string]string{
+				"first": "/persons?limit=10&page=1&sort=-age",
+				"prev":  "/persons?limit=10&page=1&sort=-age",
+				"next":  "/persons?limit=10&page=3&sort=-age",
+			},
+		},
+		{
+			name: "без last без подсчета",
+			page: model.PersonPage{Page: 1, Limit: 10, Next: []string{"10"}},
+			want: map[string]string{
+				"first": "/persons?limit=10&page=1&sort=-age",
+				"next":  "/persons?limit=10&page=2&sort=-age",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Курсор из исходного запроса не переносится в ссылки по номеру страницы
+			r := httptest.NewRequest("GET", "/persons?page=3&limit=10&sort=-age&after=abc", nil)
+			got := make(map[string]string)
+			for _, link := range pageLinks(r, tt.page) {
+				got[link.Rel] = link.URL
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pageLinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursorLinks(t *testing.T) {
+	r := httptest.NewRequest("GET", "/persons?after=abc&page=2&limit=20", nil)
+	links := cursorLinks(r, model.PersonPage{Limit: 20}, "def")
+	want := []pageLink{
+		{Rel: "first", URL: "/persons?after=&limit=20"},
+		{Rel: "next", URL: "/persons?after=def&limit=20"},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("cursorLinks() = %v, want %v", links, want)
+	}
+	if got := linkHeader(links); got != `</persons?after=&limit=20>; rel="first", </persons?after=def&limit=20>; rel="next"` {
+		t.Errorf("linkHeader() = %s", got)
+	}
+	if last := cursorLinks(r, model.PersonPage{Limit: 20}, ""); len(last) != 1 {
+		t.Errorf("cursorLinks() на последней странице = %v, want только first", last)
+	}
+}
+
+func TestGetPersonsTotalCount(t *testing.T) {
+	tests := []struct {
+		total     string
+		wantCount string
+	}{
+		{total: "", wantCount: ""},
+		{total: model.TotalNone, wantCount: ""},
+		{total: model.TotalExact, wantCount: "45"},
+		{total: model.TotalEstimated, wantCount: "45"},
+	}
+	h := NewPersonHandler(&pageStub{})
+	for _, tt := range tests {
+		t.Run("total="+tt.total, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.GetPersons(w, httptest.NewRequest("GET", "/persons?total="+tt.total, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if got := w.Header().Get("X-Total-Count"); got != tt.wantCount {
+				t.Errorf("X-Total-Count = %q, want %q", got, tt.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParsePersonFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    model.PersonFilter
+		wantErr bool
+	}{
+		{name: "без фильтров"},
+		{
+			name:  "подстроки и пол",
+			query: "name=Ivan&surname=ov&gender=male",
+			want:  model.PersonFilter{Name: "Ivan", Surname: "ov", Gender: "male"},
+		},
+		{
+			name:  "исключение пола",
+			query: "gender!=female",
+			want:  model.PersonFilter{ExcludeGender: "female"},
+		},
+		{name: "некорректное исключение пола", query: "gender!=unknown", wantErr: true},
+		{name: "некорректный пол", query: "gender=m", wantErr: true},
+		{
+			name:  "список национальностей",
+			query: "nationality=ru,%20ua%20,KZ",
+			want:  model.PersonFilter{Nationalities: []string{"RU", "UA", "KZ"}},
+		},
+		{name: "пустой элемент списка", query: "nationality=RU,,UA", wantErr: true},
+		{name: "некорректный код страны", query: "nationality=RUS", wantErr: true},
+		{
+			name:  "диапазон возраста",
+			query: "age_min=18&age_max=40",
+			want:  model.PersonFilter{AgeMin: 18, AgeMax: 40},
+		},
+		{
+			name:  "только максимальный возраст",
+			query: "age_max=0",
+			want:  model.PersonFilter{},
+		},
+		{name: "минимум больше максимума", query: "age_min=40&age_max=18", wantErr: true},
+		{name: "возраст не число", query: "age_min=x", wantErr: true},
+		{name: "отрицательный возраст", query: "age_max=-1", wantErr: true},
+		{
+			name:  "без отчества",
+			query: "no_patronymic=true",
+			want:  model.PersonFilter{NoPatronymic: true},
+		},
+		{name: "отчество и no_patronymic", query: "patronymic=ich&no_patronymic=1", wantErr: true},
+		{name: "некорректный no_patronymic", query: "no_patronymic=yes", wantErr: true},
+		{
+			name:  "статус и вероятности",
+			query: "enrichment_status=pending&min_gender_probability=0.8&min_nationality_probability=0.5",
+			want:  model.PersonFilter{EnrichmentStatus: model.EnrichmentPending, MinGenderProbability: 0.8, MinNationalityProbability: 0.5},
+		},
+		{name: "неизвестный статус", query: "enrichment_status=done", wantErr: true},
+		{name: "вероятность больше 1", query: "min_gender_probability=1.5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("url.ParseQuery() error = %v", err)
+			}
+			got, err := parsePersonFilter(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePersonFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePersonFilter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		want       []model.SortField
+		wantStable []model.SortField // Сортировка после добавления id
+		wantErr    bool
+	}{
+		{name: "по умолчанию", wantStable: []model.SortField{{Field: "id"}}},
+		{
+			name:       "направления",
+			value:      "surname,-age",
+			want:       []model.SortField{{Field: "surname"}, {Field: "age", Desc: true}},
+			wantStable: []model.SortField{{Field: "surname"}, {Field: "age", Desc: true}, {Field: "id"}},
+		},
+		{
+			name:       "пробелы вокруг полей",
+			value:      " name , -nationality ",
+			want:       []model.SortField{{Field: "name"}, {Field: "nationality", Desc: true}},
+			wantStable: []model.SortField{{Field: "name"}, {Field: "nationality", Desc: true}, {Field: "id"}},
+		},
+		{
+			name:       "id уже указан",
+			value:      "-id,surname",
+			want:       []model.SortField{{Field: "id", Desc: true}, {Field: "surname"}},
+			wantStable: []model.SortField{{Field: "id", Desc: true}, {Field: "surname"}},
+		},
+		{name: "неизвестное поле", value: "email", wantErr: true},
+		{name: "поле не из списка", value: "version", wantErr: true},
+		{name: "двойной минус", value: "--age", wantErr: true},
+		{name: "плюс не поддерживается", value: "+age", wantErr: true},
+		{name: "поле дважды", value: "age,-age", wantErr: true},
+		{name: "пустое поле", value: "age,", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSort(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSort() = %+v, want %+v", got, tt.want)
+			}
+			if stable := model.StableSort(got); !reflect.DeepEqual(stable, tt.wantStable) {
+				t.Errorf("StableSort() = %+v, want %+v", stable, tt.wantStable)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"TestEffectiveMobile/cmd/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Структура стандартного ответа об ошибке
+type ErrorResponse struct {
+	Detail string `json:"detail"`
+}
+
+// Структура стандартного ответа об успехе
+type SuccessResponse struct {
+	Message string `json:"message"`
+}
+
+// Структура ответа на добавление человека
+type AddPersonResponse struct {
+	Message    string                 `json:"message"`
+	Enrichment model.EnrichmentReport `json:"enrichment"`
+}
+
+// Результат добавления одного человека при массовой загрузке
+type BulkAddPersonItem struct {
+	Index      int                     `json:"index"`
+	Enrichment *model.EnrichmentReport `json:"enrichment,omitempty"`
+	Status     int                     `json:"status"`
+	Error      string                  `json:"error,omitempty"`
+}
+
+// Структура ответа на массовое добавление людей
+type BulkAddPersonsResponse struct {
+	Created int                 `json:"created"`
+	Failed  int                 `json:"failed"`
+	Items   []BulkAddPersonItem `json:"items"`
+}
+
+// Структура ответа на запрос страницы списка людей (envelope=true или запрос по курсору)
+type PersonsPageResponse struct {
+	Items          []model.Person `json:"items"`
+	Page           int            `json:"page,omitempty"` // Не заполняется при запросе по курсору
+	Limit          int            `json:"limit"`
+	Total          *int           `json:"total,omitempty"` // Только при total=exact или total=estimated
+	TotalEstimated bool           `json:"total_estimated,omitempty"`
+	Next           string         `json:"next,omitempty"` // Адрес следующей страницы, пустой на последней
+	Prev           string         `json:"prev,omitempty"`
+	NextCursor     string         `json:"next_cursor,omitempty"` // Пустой на последней странице
+}
+
+// Универсальный метод для ответа с JSON и статусом
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		slog.Error("Ошибка кодирования JSON", "error", err)
+	}
+}
+
+// Ответ с ETag: если клиент прислал совпадающий If-None-Match, возвращается 304 без тела
+func respondWithETag(w http.ResponseWriter, r *http.Request, etag string, payload interface{}) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, payload)
+}
+
+// personETag ETag человека по его версии
+func personETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// errInvalidIfMatch заголовок If-Match не является списком ETag
+var errInvalidIfMatch = errors.New("некорректный If-Match")
+
+// parseIfMatch возвращает версии из списка ETag в заголовке If-Match; nil означает, что заголовка нет
+// или он равен "*". If-Match сравнивает ETag строго (RFC 9110), поэтому слабые ETag (W/) и ETag,
+// не являющиеся версией, ни с чем не совпадают и в список не попадают.
+func parseIfMatch(r *http.Request) ([]int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+	versions := []int{}
+	for value = strings.TrimLeft(value, ", \t"); value != ""; value = strings.TrimLeft(value, ", \t") {
+		tag, weak := strings.CutPrefix(value, "W/")
+		if !strings.HasPrefix(tag, `"`) {
+			return nil, fmt.Errorf("%w: %s", errInvalidIfMatch, value)
+		}
+		end := strings.IndexByte(tag[1:], '"') + 1
+		if end == 0 {
+			return nil, fmt.Errorf("%w: %s", errInvalidIfMatch, value)
+		}
+		value = strings.TrimLeft(tag[end+1:], " \t")
+		if value != "" && value[0] != ',' {
+			return nil, fmt.Errorf("%w: %s", errInvalidIfMatch, value)
+		}
+		if version, err := strconv.Atoi(tag[1:end]); err == nil && version > 0 && !weak {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+// ifMatchVersion возвращает версию, в которой можно изменить человека по If-Match; 0 — без условия.
+// Если в списке несколько версий, выбирается текущая: запись все равно выполняется только в ней.
+// Если ни один ETag не совпадает, возвращается service.ErrPreconditionFailed.
+func (h *PersonHandlerImpl) ifMatchVersion(r *http.Request, id int) (int, error) {
+	versions, err := parseIfMatch(r)
+	switch {
+	case err != nil || versions == nil:
+		return 0, err
+	case len(versions) == 0:
+		return 0, service.ErrPreconditionFailed
+	case len(versions) == 1:
+		return versions[0], nil
+	}
+	current, err := h.service.GetPerson(id)
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(versions, current.Version) {
+		return 0, service.ErrPreconditionFailed
+	}
+	return current.Version, nil
+}
+
+// etagMatches сравнивает ETag со списком из If-None-Match с учетом "*" и слабых ETag (W/)
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Универсальный метод для ответа с ошибкой
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	respondWithJSON(w, code, ErrorResponse{Detail: message})
+}
+
+// Ответ с ошибкой сервиса: доменные ошибки, частичный отказ провайдеров и таймаут отличаются от внутренних ошибок
+func respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	code, message := errorStatus(err, fallback)
+	if code == http.StatusInternalServerError {
+		slog.Error(fallback, "error", err)
+	}
+	respondWithError(w, code, message)
+}
+
+// Статус и сообщение для ошибки сервиса
+func errorStatus(err error, fallback string) (int, string) {
+	var enrichErr *service.EnrichmentError
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound, "Человек не найден"
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict, "Данные конфликтуют с сохраненными"
+	case errors.Is(err, service.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, "Данные изменились, получите актуальную версию"
+	case errors.Is(err, service.ErrValidation):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "Превышено время ожидания внешних API"
+	case errors.Is(err, service.ErrCircuitOpen):
+		return http.StatusServiceUnavailable, "Внешние API временно недоступны"
+	case errors.Is(err, service.ErrQuotaExhausted), errors.Is(err, service.ErrQuotaLow):
+		return http.StatusServiceUnavailable, "Квота внешних API исчерпана"
+	case errors.As(err, &enrichErr):
+		return http.StatusBadGateway,
+			fmt.Sprintf("Не удалось получить данные из внешних API: %s", strings.Join(enrichErr.Attributes(), ", "))
+	default:
+		return http.StatusInternalServerError, fallback
+	}
+}
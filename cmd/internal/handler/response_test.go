@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"TestEffectiveMobile/cmd/internal/service"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// personStub сервис, который знает одного человека; остальные методы не вызываются
+type personStub struct {
+	service.PersonService
+	person model.Person
+}
+
+func (s *personStub) GetPerson(id int) (*model.Person, error) {
+	if id != s.person.ID {
+		return nil, service.ErrNotFound
+	}
+	person := s.person
+	return &person, nil
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    []int
+		wantErr bool
+	}{
+		{name: "без заголовка", header: "", want: nil},
+		{name: "звездочка", header: "*", want: nil},
+		{name: "одна версия", header: `"3"`, want: []int{3}},
+		{name: "список", header: `"3", "5" ,"7"`, want: []int{3, 5, 7}},
+		{name: "слабый ETag не совпадает", header: `W/"3"`, want: []int{}},
+		{name: "слабый и строгий", header: `W/"3", "4"`, want: []int{4}},
+		{name: "ETag не версия", header: `"abc", "0", "-1"`, want: []int{}},
+		{name: "запятая внутри ETag", header: `"1,2"`, want: []int{}},
+		{name: "без кавычек", header: `3`, wantErr: true},
+		{name: "незакрытая кавычка", header: `"3`, wantErr: true},
+		{name: "мусор после ETag", header: `"3" x`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/persons/1", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			got, err := parseIfMatch(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIfMatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errInvalidIfMatch) {
+				t.Errorf("parseIfMatch() error = %v, want errInvalidIfMatch", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseIfMatch() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	h := NewPersonHandler(&personStub{person: model.Person{ID: 1, Version: 5}})
+	tests := []struct {
+		name       string
+		id         int
+		header     string
+		want       int
+		wantErr    error
+		wantStatus int
+	}{
+		{name: "без условия", id: 1, want: 0},
+		{name: "звездочка", id: 1, header: "*", want: 0},
+		{name: "одна версия без чтения", id: 1, header: `"4"`, want: 4},
+		{name: "список с текущей версией", id: 1, header: `"4", "5"`, want: 5},
+		{name: "список без текущей версии", id: 1, header: `"3", "4"`, wantErr: service.ErrPreconditionFailed, wantStatus: http.StatusPreconditionFailed},
+		{name: "только слабый ETag", id: 1, header: `W/"5"`, wantErr: service.ErrPreconditionFailed, wantStatus: http.StatusPreconditionFailed},
+		{name: "список для отсутствующего человека", id: 2, header: `"4", "5"`, wantErr: service.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "некорректный заголовок", id: 1, header: `5`, wantErr: errInvalidIfMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/persons/1", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			got, err := h.ifMatchVersion(r, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ifMatchVersion() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ifMatchVersion() = %d, want %d", got, tt.want)
+			}
+			if status, _ := errorStatus(err, ""); tt.wantStatus != 0 && status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -6,11 +6,24 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func SetupRoutes(r *mux.Router, handler PersonHandler) {
+// SetupRoutes регистрирует маршруты. Служебные маршруты /admin/ доступны только с токеном adminToken.
+func SetupRoutes(r *mux.Router, handler PersonHandler, admin AdminHandler, adminToken string) {
 	r.HandleFunc("/persons/", handler.GetPersons).Methods("GET")
 	r.HandleFunc("/persons/", handler.AddPerson).Methods("POST")
+	r.HandleFunc("/persons/bulk/", handler.AddPersons).Methods("POST")
+	r.HandleFunc("/persons/{id}/", handler.GetPerson).Methods("GET")
 	r.HandleFunc("/persons/{id}/", handler.UpdatePerson).Methods("PUT")
+	r.HandleFunc("/persons/{id}/", handler.PatchPerson).Methods("PATCH")
 	r.HandleFunc("/persons/{id}/", handler.DeletePerson).Methods("DELETE")
+	r.HandleFunc("/persons/{id}/jobs/", handler.GetPersonJobs).Methods("GET")
+
+	adminRoutes := r.PathPrefix("/admin/").Subrouter()
+	adminRoutes.Use(requireAdminToken(adminToken))
+	adminRoutes.HandleFunc("/admin/enrichment/cache/", admin.GetCacheStats).Methods("GET")
+	adminRoutes.HandleFunc("/admin/enrichment/quota/", admin.GetQuota).Methods("GET")
+	adminRoutes.HandleFunc("/admin/enrichment/reenrich/", admin.StartReenrichment).Methods("POST")
+	adminRoutes.HandleFunc("/admin/enrichment/reenrich/{id}/", admin.GetReenrichment).Methods("GET")
+	r.HandleFunc("/metrics", admin.GetMetrics).Methods("GET")
 
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 }
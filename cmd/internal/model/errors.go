@@ -0,0 +1,13 @@
+package model
+
+import "errors"
+
+// Доменные ошибки. Объявлены в модели, чтобы их мог возвращать репозиторий;
+// сервис переэкспортирует их для обработчиков.
+var (
+	ErrNotFound   = errors.New("не найдено")
+	ErrConflict   = errors.New("конфликт с текущим состоянием данных")
+	ErrValidation = errors.New("некорректные данные")
+	// ErrPreconditionFailed данные изменились с версии, на которую рассчитывал клиент
+	ErrPreconditionFailed = errors.New("версия данных изменилась")
+)
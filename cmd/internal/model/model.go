@@ -1,13 +1,167 @@
 package model
 
+import (
+	"slices"
+	"time"
+)
+
+// Статусы обогащения человека
+const (
+	EnrichmentComplete = "complete" // Данные получены
+	EnrichmentPending  = "pending"  // Обогащение еще не выполнено
+	EnrichmentFailed   = "failed"   // Часть данных получить не удалось
+)
+
 type Person struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Surname     string `json:"surname"`
-	Patronymic  string `json:"patronymic,omitempty"`
-	Age         int    `json:"age"`
-	Gender      string `json:"gender"`
-	Nationality string `json:"nationality"`
+	ID                     int                 `json:"id"`
+	Name                   string              `json:"name"`
+	NormalizedName         string              `json:"normalized_name,omitempty"`
+	Surname                string              `json:"surname"`
+	Patronymic             string              `json:"patronymic,omitempty"`
+	Age                    int                 `json:"age"`
+	AgeCount               int                 `json:"age_count,omitempty"`
+	AgeSource              string              `json:"age_source,omitempty"`
+	Gender                 string              `json:"gender"`
+	GenderProbability      float64             `json:"gender_probability,omitempty"`
+	GenderSource           string              `json:"gender_source,omitempty"`
+	Nationality            string              `json:"nationality"`
+	NationalityProbability float64             `json:"nationality_probability,omitempty"`
+	Nationalities          []CountryPrediction `json:"nationalities,omitempty"`
+	NationalitySource      string              `json:"nationality_source,omitempty"`
+	LowConfidence          []string            `json:"low_confidence,omitempty"`
+	EnrichmentStatus       string              `json:"enrichment_status,omitempty"`
+	Version                int                 `json:"version,omitempty"` // Увеличивается при каждом изменении, отдается как ETag
+}
+
+// EnrichmentReport итог обогащения человека по атрибутам
+type EnrichmentReport struct {
+	PersonID int      `json:"person_id"`
+	Status   string   `json:"status"`
+	Provided []string `json:"provided"` // Переданы клиентом и не обогащались
+	Accepted []string `json:"accepted"` // Приняты
+	Rejected []string `json:"rejected"` // Отброшены как недостаточно достоверные
+	Flagged  []string `json:"flagged"`  // Сохранены с пометкой низкой достоверности
+	Failed   []string `json:"failed"`   // Не получены из-за ошибки провайдера
+}
+
+// Источники значений возраста, пола и национальности
+const (
+	SourceUser        = "user"        // Передано клиентом при создании
+	SourceManual      = "manual"      // Исправлено оператором
+	SourceAgify       = "agify"       // api.agify.io
+	SourceGenderize   = "genderize"   // api.genderize.io
+	SourceNationalize = "nationalize" // api.nationalize.io
+	SourceLocal       = "local"       // Локальный справочник имен
+	SourceStatic      = "static"      // Статический провайдер для тестов
+)
+
+// Статусы задания на обогащение
+const (
+	JobQueued  = "queued"  // Ожидает выполнения
+	JobRunning = "running" // Выполняется воркером
+	JobDone    = "done"    // Выполнено
+	JobDead    = "dead"    // Исчерпаны попытки
+)
+
+// EnrichmentJob задание на фоновое обогащение человека
+type EnrichmentJob struct {
+	ID        int       `json:"id"`
+	PersonID  int       `json:"person_id"`
+	CountryID string    `json:"country_id,omitempty"`
+	Status    string    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	RunAt     time.Time `json:"run_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PersonFilter отбирает людей для списка; пустые поля не ограничивают выборку
+type PersonFilter struct {
+	Name                      string
+	NormalizedName            string // Нормализованное имя для поиска независимо от алфавита и регистра
+	Surname                   string // Подстрока фамилии
+	Patronymic                string // Подстрока отчества
+	NoPatronymic              bool   // Только люди без отчества
+	Gender                    string
+	ExcludeGender             string   // Пол не равен указанному, люди с неизвестным полом подходят
+	Nationalities             []string // Национальность — одна из указанных
+	AgeMin                    int
+	AgeMax                    int
+	EnrichmentStatus          string
+	MinGenderProbability      float64
+	MinNationalityProbability float64
+}
+
+// SortField поле сортировки списка; Desc — по убыванию
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// PersonSortFields поля, по которым можно сортировать список людей
+var PersonSortFields = []string{"id", "name", "surname", "patronymic", "age", "gender", "nationality"}
+
+// StableSort добавляет в конец сортировку по id, если ее нет, чтобы порядок строк был однозначным
+func StableSort(sort []SortField) []SortField {
+	for _, field := range sort {
+		if field.Field == "id" {
+			return sort
+		}
+	}
+	return append(slices.Clone(sort), SortField{Field: "id"})
+}
+
+// Способы подсчета общего числа строк списка
+const (
+	TotalNone      = "none"      // Не считать
+	TotalExact     = "exact"     // Точный подсчет
+	TotalEstimated = "estimated" // Оценка планировщика по статистике таблицы
+)
+
+// PageRequest параметры страницы списка. Если After задан, страница начинается после строки
+// с этими значениями полей StableSort(Sort), а Page не используется.
+// Total задает способ подсчета общего числа строк; пустое значение равно TotalNone.
+type PageRequest struct {
+	Page  int
+	Limit int
+	Sort  []SortField
+	After []string
+	Total string
+}
+
+// PersonPage страница списка людей
+type PersonPage struct {
+	Items          []Person
+	Page           int
+	Limit          int
+	Next           []string // Значения полей сортировки последней строки, если есть следующая страница
+	Total          int      // Число людей, подходящих под фильтр, на всех страницах
+	TotalKnown     bool     // Total подсчитано, то есть запрошено в PageRequest.Total
+	TotalEstimated bool     // Total — оценка планировщика, а не точное число
+}
+
+// ReenrichFilter отбирает людей для повторного обогащения; пустые поля не ограничивают выборку
+type ReenrichFilter struct {
+	FromID         int       `json:"from_id"`
+	ToID           int       `json:"to_id"`
+	Missing        []string  `json:"missing"`         // Не заполнен хотя бы один из атрибутов
+	Sources        []string  `json:"sources"`         // Хотя бы один атрибут получен из одного из источников
+	EnrichedBefore time.Time `json:"enriched_before"` // Последнее обогащение раньше этого времени или не выполнялось
+	Limit          int       `json:"limit"`           // Максимальное число людей
+}
+
+type AgifyResponse struct {
+	Name  string `json:"name"`
+	Age   int    `json:"age"`
+	Count int    `json:"count"`
+}
+
+type GenderizeResponse struct {
+	Name        string  `json:"name"`
+	Gender      string  `json:"gender"`
+	Probability float64 `json:"probability"`
+	Count       int     `json:"count"`
 }
 
 type NationalizeResponse struct {
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// EnrichmentCacheRepository хранит закешированные результаты обогащения
+type EnrichmentCacheRepository interface {
+	GetCachedEnrichment(key string, maxAge time.Duration) ([]byte, bool, error)
+	SaveCachedEnrichment(key string, payload []byte) error
+}
+
+type EnrichmentCacheRepositoryPgSQL struct {
+	db *sql.DB
+}
+
+func NewEnrichmentCacheRepositoryPgSQL(db *sql.DB) *EnrichmentCacheRepositoryPgSQL {
+	return &EnrichmentCacheRepositoryPgSQL{db: db}
+}
+
+func (r *EnrichmentCacheRepositoryPgSQL) GetCachedEnrichment(key string, maxAge time.Duration) ([]byte, bool, error) {
+	var payload []byte
+	err := r.db.QueryRow("SELECT payload FROM enrichment_cache WHERE key=$1 AND updated_at > now() - $2::float8 * interval '1 second'",
+		key, maxAge.Seconds()).Scan(&payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return payload, true, nil
+}
+
+func (r *EnrichmentCacheRepositoryPgSQL) SaveCachedEnrichment(key string, payload []byte) error {
+	_, err := r.db.Exec(`INSERT INTO enrichment_cache (key, payload, updated_at) VALUES ($1, $2, now())
+		ON CONFLICT (key) DO UPDATE SET payload = EXCLUDED.payload, updated_at = EXCLUDED.updated_at`, key, payload)
+	return err
+}
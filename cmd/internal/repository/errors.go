@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+)
+
+// Коды ошибок PostgreSQL, которые переводятся в доменные ошибки
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgCheckViolation      = "23514"
+	pgStringTooLong       = "22001"
+	pgInvalidText         = "22P02"
+)
+
+// mapError переводит ошибки БД в доменные ошибки model.ErrNotFound, model.ErrConflict и model.ErrValidation.
+// Текст нарушения ограничения сохраняется в сообщении, остальные ошибки возвращаются как есть.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case pgUniqueViolation, pgForeignKeyViolation:
+		return fmt.Errorf("%w: %s", model.ErrConflict, pqErr.Message)
+	case pgNotNullViolation, pgCheckViolation, pgStringTooLong, pgInvalidText:
+		return fmt.Errorf("%w: %s", model.ErrValidation, pqErr.Message)
+	default:
+		return err
+	}
+}
+
+// checkAffected возвращает model.ErrNotFound, если запрос не изменил ни одной строки
+func checkAffected(result sql.Result, err error) error {
+	if err != nil {
+		return mapError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// writeError переводит ошибку изменения человека с RETURNING: отсутствие строки означает,
+// что человека нет или его версия уже не version
+func (r *PersonRepositoryPgSQL) writeError(err error, id, version int) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return r.versionError(id, version)
+	}
+	return mapError(err)
+}
+
+// versionError выясняет, почему запрос не изменил человека: model.ErrPreconditionFailed,
+// если человек есть, но в другой версии, иначе model.ErrNotFound
+func (r *PersonRepositoryPgSQL) versionError(id, version int) error {
+	if version == 0 {
+		return model.ErrNotFound
+	}
+	var exists bool
+	if err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM persons WHERE id = $1)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return model.ErrPreconditionFailed
+	}
+	return model.ErrNotFound
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"database/sql"
+	"time"
+)
+
+// JobRepository хранит очередь заданий на обогащение
+type JobRepository interface {
+	EnqueueEnrichmentJob(personID int, countryID string) error
+	ClaimEnrichmentJobs(limit int, lease time.Duration) ([]model.EnrichmentJob, error)
+	CompleteEnrichmentJob(id int) error
+	RetryEnrichmentJob(id int, lastError string, runAt time.Time) error
+	DeadLetterEnrichmentJob(id int, lastError string) error
+	GetEnrichmentJobs(personID int) ([]model.EnrichmentJob, error)
+}
+
+const jobColumns = "id, person_id, COALESCE(country_id, ''), status, attempts, COALESCE(last_error, ''), run_at, created_at, updated_at"
+
+type JobRepositoryPgSQL struct {
+	db *sql.DB
+}
+
+func NewJobRepositoryPgSQL(db *sql.DB) *JobRepositoryPgSQL {
+	return &JobRepositoryPgSQL{db: db}
+}
+
+func (r *JobRepositoryPgSQL) EnqueueEnrichmentJob(personID int, countryID string) error {
+	_, err := r.db.Exec("INSERT INTO enrichment_jobs (person_id, country_id, status) VALUES ($1, NULLIF($2, ''), $3)",
+		personID, countryID, model.JobQueued)
+	return mapError(err)
+}
+
+// ClaimEnrichmentJobs забирает до limit готовых к выполнению заданий.
+// Задания, заблокированные другими воркерами, пропускаются; задания упавшего воркера
+// возвращаются в работу по истечении lease.
+func (r *JobRepositoryPgSQL) ClaimEnrichmentJobs(limit int, lease time.Duration) ([]model.EnrichmentJob, error) {
+	rows, err := r.db.Query(`UPDATE enrichment_jobs SET status = $1, attempts = attempts + 1, locked_at = now(), updated_at = now()
+		WHERE id IN (
+			SELECT id FROM enrichment_jobs
+			WHERE (status = $2 AND run_at <= now())
+			   OR (status = $1 AND locked_at < now() - $3::float8 * interval '1 second')
+			ORDER BY run_at, id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns,
+		model.JobRunning, model.JobQueued, lease.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanJobs(rows)
+}
+
+func (r *JobRepositoryPgSQL) CompleteEnrichmentJob(id int) error {
+	_, err := r.db.Exec("UPDATE enrichment_jobs SET status = $1, last_error = NULL, locked_at = NULL, updated_at = now() WHERE id = $2",
+		model.JobDone, id)
+	return err
+}
+
+func (r *JobRepositoryPgSQL) RetryEnrichmentJob(id int, lastError string, runAt time.Time) error {
+	_, err := r.db.Exec("UPDATE enrichment_jobs SET status = $1, last_error = $2, run_at = $3, locked_at = NULL, updated_at = now() WHERE id = $4",
+		model.JobQueued, lastError, runAt, id)
+	return err
+}
+
+func (r *JobRepositoryPgSQL) DeadLetterEnrichmentJob(id int, lastError string) error {
+	_, err := r.db.Exec("UPDATE enrichment_jobs SET status = $1, last_error = $2, locked_at = NULL, updated_at = now() WHERE id = $3",
+		model.JobDead, lastError, id)
+	return err
+}
+
+func (r *JobRepositoryPgSQL) GetEnrichmentJobs(personID int) ([]model.EnrichmentJob, error) {
+	rows, err := r.db.Query("SELECT "+jobColumns+" FROM enrichment_jobs WHERE person_id = $1 ORDER BY id", personID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return scanJobs(rows)
+}
+
+func scanJobs(rows *sql.Rows) ([]model.EnrichmentJob, error) {
+	defer rows.Close()
+
+	jobs := []model.EnrichmentJob{}
+	for rows.Next() {
+		var j model.EnrichmentJob
+		if err := rows.Scan(&j.ID, &j.PersonID, &j.CountryID, &j.Status, &j.Attempts, &j.LastError, &j.RunAt, &j.CreatedAt, &j.UpdatedAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"fmt"
+	"github.com/lib/pq"
+	"strconv"
+	"strings"
+)
+
+// query собирает условия WHERE; значения передаются только позиционными параметрами $1, $2, ...
+// Имена столбцов подставляются в SQL как есть, поэтому они должны быть константами, а не данными запроса.
+type query struct {
+	conditions []string
+	args       []any
+}
+
+// condition условие WHERE, добавляющее свои значения в параметры запроса
+type condition func(q *query) string
+
+// where добавляет условия, объединяемые через AND
+func (q *query) where(conds ...condition) *query {
+	for _, cond := range conds {
+		q.conditions = append(q.conditions, cond(q))
+	}
+	return q
+}
+
+// arg добавляет значение в параметры запроса и возвращает его плейсхолдер
+func (q *query) arg(value any) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// whereSQL возвращает предложение WHERE или пустую строку, если условий нет
+func (q *query) whereSQL() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+// eq column = value
+func eq(column string, value any) condition {
+	return func(q *query) string { return column + " = " + q.arg(value) }
+}
+
+// notEq column не равен value; строки с NULL тоже подходят
+func notEq(column string, value any) condition {
+	return func(q *query) string { return column + " IS DISTINCT FROM " + q.arg(value) }
+}
+
+// in column равен одному из значений
+func in[T any](column string, values []T) condition {
+	return func(q *query) string { return column + " = ANY(" + q.arg(pq.Array(values)) + ")" }
+}
+
+// gte column >= value
+func gte(column string, value any) condition {
+	return func(q *query) string { return column + " >= " + q.arg(value) }
+}
+
+// lte column <= value
+func lte(column string, value any) condition {
+	return func(q *query) string { return column + " <= " + q.arg(value) }
+}
+
+// gt column > value
+func gt(column string, value any) condition {
+	return func(q *query) string { return column + " > " + q.arg(value) }
+}
+
+// lt column < value
+func lt(column string, value any) condition {
+	return func(q *query) string { return column + " < " + q.arg(value) }
+}
+
+// contains column содержит подстроку без учета регистра; % и _ в подстроке ищутся как обычные символы
+func contains(column, substring string) condition {
+	return func(q *query) string {
+		return column + " ILIKE " + q.arg("%"+escapeLike(substring)+"%") + ` ESCAPE '\'`
+	}
+}
+
+// isNull column IS NULL
+func isNull(column string) condition {
+	return func(q *query) string { return column + " IS NULL" }
+}
+
+// or выполняется, если выполнено хотя бы одно из условий; без условий не выполняется никогда
+func or(conds ...condition) condition {
+	return func(q *query) string {
+		if len(conds) == 0 {
+			return "FALSE"
+		}
+		parts := make([]string, len(conds))
+		for i, cond := range conds {
+			parts[i] = cond(q)
+		}
+		return "(" + strings.Join(parts, " OR ") + ")"
+	}
+}
+
+// and выполняется, если выполнены все условия
+func and(conds ...condition) condition {
+	return func(q *query) string {
+		if len(conds) == 0 {
+			return "TRUE"
+		}
+		parts := make([]string, len(conds))
+		for i, cond := range conds {
+			parts[i] = cond(q)
+		}
+		return "(" + strings.Join(parts, " AND ") + ")"
+	}
+}
+
+// sortColumns возвращает выражения columns для полей model.StableSort(sort)
+func sortColumns(sort []model.SortField, columns map[string]string) ([]string, []model.SortField, error) {
+	sort = model.StableSort(sort)
+	exprs := make([]string, len(sort))
+	for i, field := range sort {
+		column, ok := columns[field.Field]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: неизвестное поле сортировки %s", model.ErrValidation, field.Field)
+		}
+		exprs[i] = column
+	}
+	return exprs, sort, nil
+}
+
+// orderBy возвращает предложение ORDER BY по полям sort с выражениями из columns.
+// Если сортировки по id нет, она добавляется последней, чтобы порядок строк был однозначным.
+func orderBy(sort []model.SortField, columns map[string]string) (string, error) {
+	exprs, sort, err := sortColumns(sort, columns)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, len(sort))
+	for i, field := range sort {
+		parts[i] = exprs[i] + " ASC"
+		if field.Desc {
+			parts[i] = exprs[i] + " DESC"
+		}
+	}
+	return " ORDER BY " + strings.Join(parts, ", "), nil
+}
+
+// keysetAfter отбирает строки, идущие в порядке orderBy(sort) после строки со значениями values
+// полей model.StableSort(sort): (a > x) OR (a = x AND b < y) OR (a = x AND b = y AND id > z) ...
+func keysetAfter(sort []model.SortField, columns map[string]string, values []string) (condition, error) {
+	exprs, sort, err := sortColumns(sort, columns)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(sort) {
+		return nil, fmt.Errorf("%w: курсор не соответствует сортировке", model.ErrValidation)
+	}
+
+	branches := make([]condition, len(sort))
+	for i, field := range sort {
+		var prefix []condition
+		for j := range i {
+			prefix = append(prefix, eq(exprs[j], values[j]))
+		}
+		next := gt(exprs[i], values[i])
+		if field.Desc {
+			next = lt(exprs[i], values[i])
+		}
+		branches[i] = and(append(prefix, next)...)
+	}
+	return or(branches...), nil
+}
+
+// likeEscaper экранирует служебные символы шаблона LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike экранирует %, _ и \ для поиска подстроки через LIKE с ESCAPE '\'
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
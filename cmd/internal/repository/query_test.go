@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"errors"
+	"github.com/lib/pq"
+	"reflect"
+	"testing"
+)
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"ivan", "ivan"},
+		{"100%", `100\%`},
+		{"a_b", `a\_b`},
+		{`C:\path`, `C:\\path`},
+		{`%_\`, `\%\_\\`},
+		{"'; DROP TABLE persons; --", "'; DROP TABLE persons; --"},
+	}
+	for _, tt := range tests {
+		if got := escapeLike(tt.in); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQueryWhere(t *testing.T) {
+	tests := []struct {
+		name     string
+		conds    []condition
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:    "без условий",
+			wantSQL: "",
+		},
+		{
+			name:     "значения только в параметрах",
+			conds:    []condition{eq("gender", "male' OR '1'='1"), contains("surname", "50%_")},
+			wantSQL:  ` WHERE gender = $1 AND surname ILIKE $2 ESCAPE '\'`,
+			wantArgs: []any{"male' OR '1'='1", `%50\%\_%`},
+		},
+		{
+			name: "нумерация через or, and и in",
+			conds: []condition{
+				gte("age", 18),
+				or(contains("name", "ivan"), and(in("nationality", []string{"RU", "UA"}), notEq("gender", "male"))),
+				lte("age", 40),
+			},
+			wantSQL: ` WHERE age >= $1 AND (name ILIKE $2 ESCAPE '\' OR (nationality = ANY($3) AND gender IS DISTINCT FROM $4))` +
+				` AND age <= $5`,
+			wantArgs: []any{18, "%ivan%", pq.Array([]string{"RU", "UA"}), "male", 40},
+		},
+		{
+			name:    "пустые or и and",
+			conds:   []condition{or(), and(), isNull("patronymic")},
+			wantSQL: " WHERE FALSE AND TRUE AND patronymic IS NULL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := (&query{}).where(tt.conds...)
+			if got := q.whereSQL(); got != tt.wantSQL {
+				t.Errorf("whereSQL() = %q, want %q", got, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(q.args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", q.args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	columns := map[string]string{"id": "id", "surname": "surname", "age": "COALESCE(age, 0)"}
+	tests := []struct {
+		name    string
+		sort    []model.SortField
+		want    string
+		wantErr error
+	}{
+		{name: "по умолчанию", want: " ORDER BY id ASC"},
+		{
+			name: "id добавляется последним",
+			sort: []model.SortField{{Field: "surname"}, {Field: "age", Desc: true}},
+			want: " ORDER BY surname ASC, COALESCE(age, 0) DESC, id ASC",
+		},
+		{
+			name: "id не дублируется",
+			sort: []model.SortField{{Field: "id", Desc: true}, {Field: "surname"}},
+			want: " ORDER BY id DESC, surname ASC",
+		},
+		{
+			name:    "неизвестное поле",
+			sort:    []model.SortField{{Field: "name; DROP TABLE persons"}},
+			wantErr: model.ErrValidation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := orderBy(tt.sort, columns)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("orderBy() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("orderBy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysetAfter(t *testing.T) {
+	columns := map[string]string{"id": "id", "surname": "surname", "age": "COALESCE(age, 0)"}
+	tests := []struct {
+		name     string
+		sort     []model.SortField
+		values   []string
+		wantSQL  string
+		wantArgs []any
+		wantErr  error
+	}{
+		{
+			name:     "только id",
+			values:   []string{"42"},
+			wantSQL:  " WHERE ((id > $1))",
+			wantArgs: []any{"42"},
+		},
+		{
+			name:   "смешанные направления",
+			sort:   []model.SortField{{Field: "surname"}, {Field: "age", Desc: true}},
+			values: []string{"Ivanov", "30", "7"},
+			wantSQL: " WHERE ((surname > $1)" +
+				" OR (surname = $2 AND COALESCE(age, 0) < $3)" +
+				" OR (surname = $4 AND COALESCE(age, 0) = $5 AND id > $6))",
+			wantArgs: []any{"Ivanov", "Ivanov", "30", "Ivanov", "30", "7"},
+		},
+		{
+			name:     "убывание по id",
+			sort:     []model.SortField{{Field: "id", Desc: true}},
+			values:   []string{"10"},
+			wantSQL:  " WHERE ((id < $1))",
+			wantArgs: []any{"10"},
+		},
+		{
+			name:    "число значений не совпадает с сортировкой",
+			sort:    []model.SortField{{Field: "surname"}},
+			values:  []string{"Ivanov"},
+			wantErr: model.ErrValidation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, err := keysetAfter(tt.sort, columns, tt.values)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("keysetAfter() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			q := (&query{}).where(cond)
+			if got := q.whereSQL(); got != tt.wantSQL {
+				t.Errorf("whereSQL() = %q, want %q", got, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(q.args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", q.args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -3,18 +3,36 @@ package repository
 import (
 	"TestEffectiveMobile/cmd/internal/model"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/lib/pq"
 	"log/slog"
 )
 
 type PersonRepository interface {
-	SavePerson(name, surname, patronymic string, age int, gender, nationality string) error
-	DeletePerson(id int) error
-	UpdatePerson(id int, name, surname, patronymic string, age int, gender, nationality string) error
+	SavePerson(person model.Person) (int, error)
+	SavePersonWithJob(person model.Person, countryID string) (int, error)
+	DeletePerson(id, version int) error
+	UpdatePerson(person model.Person) (int, error)
+	UpdateEnrichment(person model.Person) error
+	PatchPerson(person model.Person, enriched bool) (int, error)
+	PatchPersonWithJob(person model.Person, countryID string) (int, error)
 	GetPerson(id int) (*model.Person, error)
-	GetAllPersons(page, limit int, name, gender, nationality string) ([]model.Person, error)
+	GetAllPersons(filter model.PersonFilter, page model.PageRequest) ([]model.Person, bool, error)
+	CountPersons(filter model.PersonFilter, estimate bool) (int, error)
+	GetPersonsForReenrichment(filter model.ReenrichFilter, afterID, limit int) ([]model.Person, error)
+	GetPersonsWithoutNormalizedName(afterID, limit int) ([]model.Person, error)
+	SetNormalizedName(id int, normalizedName string) error
 }
 
+// Столбцы человека; отсутствующие данные обогащения хранятся как NULL
+const personColumns = `id, name, COALESCE(normalized_name, ''), surname, COALESCE(patronymic, ''),
+	COALESCE(age, 0), COALESCE(age_count, 0), COALESCE(age_source, ''),
+	COALESCE(gender, ''), COALESCE(gender_probability, 0), COALESCE(gender_source, ''),
+	COALESCE(nationality, ''), COALESCE(nationality_probability, 0), nationalities, COALESCE(nationality_source, ''),
+	low_confidence, enrichment_status, version`
+
 type PersonRepositoryPgSQL struct {
 	db *sql.DB
 }
@@ -23,72 +41,391 @@ func NewPersonRepositoryPgSQL(db *sql.DB) *PersonRepositoryPgSQL {
 	return &PersonRepositoryPgSQL{db: db}
 }
 
-func (r *PersonRepositoryPgSQL) SavePerson(name, surname, patronymic string, age int, gender, nationality string) error {
-	_, err := r.db.Exec("INSERT INTO persons (name, surname, patronymic, age, gender, nationality) VALUES ($1, $2, $3, $4, $5, $6)", name, surname, patronymic, age, gender, nationality)
-	return err
+// insertPersonSQL добавляет человека с аргументами insertPersonArgs; RETURNING дописывается вызывающим
+const insertPersonSQL = `INSERT INTO persons (name, surname, patronymic,
+			age, age_count, age_source, gender, gender_probability, gender_source,
+			nationality, nationality_probability, nationalities, nationality_source,
+			low_confidence, enrichment_status, normalized_name, enriched_at)
+		VALUES ($1, $2, $3,
+			NULLIF($4, 0), NULLIF($5, 0), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, 0::float8), NULLIF($9, ''),
+			NULLIF($10, ''), NULLIF($11, 0::float8), $12::jsonb, NULLIF($13, ''),
+			$14, $15, NULLIF($16, ''), CASE WHEN $15 <> 'pending' THEN now() END)`
+
+// insertPersonArgs возвращает аргументы $1..$16 для insertPersonSQL
+func insertPersonArgs(person model.Person) ([]any, error) {
+	nationalities, err := marshalNationalities(person.Nationalities)
+	if err != nil {
+		return nil, err
+	}
+	return []any{person.Name, person.Surname, person.Patronymic,
+		person.Age, person.AgeCount, person.AgeSource, person.Gender, person.GenderProbability, person.GenderSource,
+		person.Nationality, person.NationalityProbability, nationalities, person.NationalitySource,
+		pq.Array(person.LowConfidence), person.EnrichmentStatus, person.NormalizedName}, nil
+}
+
+func (r *PersonRepositoryPgSQL) SavePerson(person model.Person) (int, error) {
+	args, err := insertPersonArgs(person)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = r.db.QueryRow(insertPersonSQL+" RETURNING id", args...).Scan(&id)
+	return id, mapError(err)
+}
+
+// SavePersonWithJob добавляет человека и задание на его обогащение одним запросом:
+// человек без задания не сохраняется, если поставить задание не удалось
+func (r *PersonRepositoryPgSQL) SavePersonWithJob(person model.Person, countryID string) (int, error) {
+	args, err := insertPersonArgs(person)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = r.db.QueryRow(`WITH saved AS (`+insertPersonSQL+` RETURNING id),
+		job AS (INSERT INTO enrichment_jobs (person_id, country_id, status) SELECT id, NULLIF($17, ''), $18 FROM saved)
+		SELECT id FROM saved`,
+		append(args, countryID, model.JobQueued)...).Scan(&id)
+	return id, mapError(err)
 }
 
-func (r *PersonRepositoryPgSQL) DeletePerson(id int) error {
-	_, err := r.db.Exec("DELETE FROM persons WHERE id = $1", id)
+// DeletePerson удаляет человека. Если version не 0, человек удаляется только в этой версии,
+// иначе возвращается model.ErrPreconditionFailed; если человека нет, возвращается model.ErrNotFound.
+func (r *PersonRepositoryPgSQL) DeletePerson(id, version int) error {
+	err := checkAffected(r.db.Exec("DELETE FROM persons WHERE id = $1 AND ($2 = 0 OR version = $2)", id, version))
+	if errors.Is(err, model.ErrNotFound) {
+		return r.versionError(id, version)
+	}
 	return err
 }
 
-func (r *PersonRepositoryPgSQL) UpdatePerson(id int, name, surname, patronymic string, age int, gender, nationality string) error {
-	_, err := r.db.Exec("UPDATE persons SET name=$1, surname=$2, patronymic=$3, age=$4, gender=$5, nationality=$6 WHERE id=$7",
-		name, surname, patronymic, age, gender, nationality, id)
+// UpdatePerson перезаписывает данные человека; данные обогащения заменяются значениями оператора.
+// Если person.Version не 0, запись выполняется только в этой версии. Возвращает новую версию.
+func (r *PersonRepositoryPgSQL) UpdatePerson(person model.Person) (int, error) {
+	var version int
+	err := r.db.QueryRow(`UPDATE persons SET name=$1, surname=$2, patronymic=$3,
+			age=NULLIF($4, 0), age_count=NULL, age_source=NULLIF($5, ''),
+			gender=NULLIF($6, ''), gender_probability=NULL, gender_source=NULLIF($7, ''),
+			nationality=NULLIF($8, ''), nationality_probability=NULL, nationalities=NULL, nationality_source=NULLIF($9, ''),
+			low_confidence=NULL, normalized_name=NULLIF($11, ''), version=version+1
+		WHERE id=$10 AND ($12 = 0 OR version=$12) RETURNING version`,
+		person.Name, person.Surname, person.Patronymic,
+		person.Age, person.AgeSource, person.Gender, person.GenderSource, person.Nationality, person.NationalitySource,
+		person.ID, person.NormalizedName, person.Version).Scan(&version)
+	return version, r.writeError(err, person.ID, person.Version)
+}
+
+// UpdateEnrichment сохраняет результаты обогащения. Если person.Version не 0 и человека успели
+// изменить после чтения, возвращается model.ErrPreconditionFailed, чтобы не затереть правки оператора.
+// Непустое person.NormalizedName заодно записывается в БД.
+func (r *PersonRepositoryPgSQL) UpdateEnrichment(person model.Person) error {
+	nationalities, err := marshalNationalities(person.Nationalities)
+	if err != nil {
+		return err
+	}
+
+	err = checkAffected(r.db.Exec(`UPDATE persons SET
+			age=NULLIF($1, 0), age_count=NULLIF($2, 0), age_source=NULLIF($3, ''),
+			gender=NULLIF($4, ''), gender_probability=NULLIF($5, 0::float8), gender_source=NULLIF($6, ''),
+			nationality=NULLIF($7, ''), nationality_probability=NULLIF($8, 0::float8), nationalities=$9::jsonb,
+			nationality_source=NULLIF($10, ''),
+			low_confidence=$11, enrichment_status=$12, enriched_at=now(), version=version+1,
+			normalized_name=COALESCE(NULLIF($15, ''), normalized_name)
+		WHERE id=$13 AND ($14 = 0 OR version=$14)`,
+		person.Age, person.AgeCount, person.AgeSource,
+		person.Gender, person.GenderProbability, person.GenderSource,
+		person.Nationality, person.NationalityProbability, nationalities, person.NationalitySource,
+		pq.Array(person.LowConfidence), person.EnrichmentStatus, person.ID, person.Version, person.NormalizedName))
+	if errors.Is(err, model.ErrNotFound) {
+		return r.versionError(person.ID, person.Version)
+	}
 	return err
 }
 
-func (r *PersonRepositoryPgSQL) GetPerson(id int) (*model.Person, error) {
-	row := r.db.QueryRow("SELECT id, name, surname, patronymic, age, gender, nationality FROM persons WHERE id=$1", id)
-	var p model.Person
-	err := row.Scan(&p.ID, &p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality)
+// PatchPerson сохраняет все поля человека вместе с данными обогащения и их источниками.
+// enriched обновляет время последнего обогащения. Если person.Version не 0, запись выполняется
+// только в этой версии. Возвращает новую версию.
+func (r *PersonRepositoryPgSQL) PatchPerson(person model.Person, enriched bool) (int, error) {
+	args, err := patchPersonArgs(person, enriched)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = r.db.QueryRow(patchPersonSQL+" RETURNING version", args...).Scan(&version)
+	return version, r.writeError(err, person.ID, person.Version)
+}
+
+// PatchPersonWithJob сохраняет человека, как PatchPerson без enriched, и ставит задание
+// на его обогащение одним запросом. Возвращает новую версию.
+func (r *PersonRepositoryPgSQL) PatchPersonWithJob(person model.Person, countryID string) (int, error) {
+	args, err := patchPersonArgs(person, false)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = r.db.QueryRow(`WITH patched AS (`+patchPersonSQL+` RETURNING id, version),
+		job AS (INSERT INTO enrichment_jobs (person_id, country_id, status) SELECT id, NULLIF($20, ''), $21 FROM patched)
+		SELECT version FROM patched`,
+		append(args, countryID, model.JobQueued)...).Scan(&version)
+	return version, r.writeError(err, person.ID, person.Version)
+}
+
+// patchPersonSQL сохраняет все поля человека с аргументами patchPersonArgs; RETURNING дописывается вызывающим
+const patchPersonSQL = `UPDATE persons SET name=$1, surname=$2, patronymic=$3, normalized_name=NULLIF($4, ''),
+			age=NULLIF($5, 0), age_count=NULLIF($6, 0), age_source=NULLIF($7, ''),
+			gender=NULLIF($8, ''), gender_probability=NULLIF($9, 0::float8), gender_source=NULLIF($10, ''),
+			nationality=NULLIF($11, ''), nationality_probability=NULLIF($12, 0::float8), nationalities=$13::jsonb,
+			nationality_source=NULLIF($14, ''),
+			low_confidence=$15, enrichment_status=$16, enriched_at=CASE WHEN $17 THEN now() ELSE enriched_at END,
+			version=version+1
+		WHERE id=$18 AND ($19 = 0 OR version=$19)`
+
+// patchPersonArgs возвращает аргументы $1..$19 для patchPersonSQL
+func patchPersonArgs(person model.Person, enriched bool) ([]any, error) {
+	nationalities, err := marshalNationalities(person.Nationalities)
 	if err != nil {
 		return nil, err
 	}
+	return []any{person.Name, person.Surname, person.Patronymic, person.NormalizedName,
+		person.Age, person.AgeCount, person.AgeSource,
+		person.Gender, person.GenderProbability, person.GenderSource,
+		person.Nationality, person.NationalityProbability, nationalities, person.NationalitySource,
+		pq.Array(person.LowConfidence), person.EnrichmentStatus, enriched, person.ID, person.Version}, nil
+}
+
+// GetPerson возвращает человека по ID; если его нет, возвращается model.ErrNotFound
+func (r *PersonRepositoryPgSQL) GetPerson(id int) (*model.Person, error) {
+	row := r.db.QueryRow("SELECT "+personColumns+" FROM persons WHERE id=$1", id)
+	p, err := scanPerson(row)
+	if err != nil {
+		return nil, mapError(err)
+	}
 	return &p, nil
 }
 
-func (r *PersonRepositoryPgSQL) GetAllPersons(page, limit int, name, gender, nationality string) ([]model.Person, error) {
-	var people []model.Person
+// personSortColumns выражения для сортировки по полям model.PersonSortFields.
+// Отсутствующие значения сортируются как наименьшие, чтобы порядок не зависел от NULL.
+var personSortColumns = map[string]string{
+	"id":          "id",
+	"name":        "name",
+	"surname":     "surname",
+	"patronymic":  "COALESCE(patronymic, '')",
+	"age":         "COALESCE(age, 0)",
+	"gender":      "COALESCE(gender, '')",
+	"nationality": "COALESCE(nationality, '')",
+}
 
-	// Строим SQL запрос с фильтрами
-	query := "SELECT id, name, surname, patronymic, age, gender, nationality FROM persons WHERE 1=1"
+// GetAllPersons возвращает страницу людей, подходящих под фильтр, в порядке page.Sort с id в конце;
+// пустые поля фильтра не ограничивают выборку. Страница выбирается по курсору page.After,
+// а без него — смещением по номеру страницы. Второе значение сообщает, есть ли люди после страницы.
+func (r *PersonRepositoryPgSQL) GetAllPersons(filter model.PersonFilter, page model.PageRequest) ([]model.Person, bool, error) {
+	order, err := orderBy(page.Sort, personSortColumns)
+	if err != nil {
+		return nil, false, err
+	}
 
-	// Добавляем фильтры
-	if name != "" {
-		query += fmt.Sprintf(" AND name ILIKE '%%%s%%'", name)
+	q := personFilterQuery(filter)
+	offset := (page.Page - 1) * page.Limit
+	if page.After != nil {
+		after, err := keysetAfter(page.Sort, personSortColumns, page.After)
+		if err != nil {
+			return nil, false, err
+		}
+		q.where(after)
+		offset = 0
 	}
-	if gender != "" {
-		query += fmt.Sprintf(" AND gender = '%s'", gender)
+
+	// Выбираем на одного человека больше, чтобы узнать, есть ли следующая страница
+	stmt := "SELECT " + personColumns + " FROM persons" + q.whereSQL() + order +
+		" LIMIT " + q.arg(page.Limit+1) + " OFFSET " + q.arg(offset)
+	people, err := r.queryPersons(stmt, q.args)
+	if err != nil || len(people) <= page.Limit {
+		return people, false, err
 	}
-	if nationality != "" {
-		query += fmt.Sprintf(" AND nationality = '%s'", nationality)
+	return people[:page.Limit], true, nil
+}
+
+// personFilterQuery переводит фильтр списка людей в условия WHERE
+func personFilterQuery(filter model.PersonFilter) *query {
+	q := &query{}
+	if filter.Name != "" {
+		q.where(or(contains("name", filter.Name), contains("normalized_name", filter.NormalizedName)))
 	}
+	if filter.Surname != "" {
+		q.where(contains("surname", filter.Surname))
+	}
+	if filter.Patronymic != "" {
+		q.where(contains("patronymic", filter.Patronymic))
+	}
+	if filter.NoPatronymic {
+		q.where(or(isNull("patronymic"), eq("patronymic", "")))
+	}
+	if filter.Gender != "" {
+		q.where(eq("gender", filter.Gender))
+	}
+	if filter.ExcludeGender != "" {
+		q.where(notEq("gender", filter.ExcludeGender))
+	}
+	if len(filter.Nationalities) > 0 {
+		q.where(in("nationality", filter.Nationalities))
+	}
+	if filter.AgeMin > 0 {
+		q.where(gte("age", filter.AgeMin))
+	}
+	if filter.AgeMax > 0 {
+		q.where(lte("age", filter.AgeMax))
+	}
+	if filter.EnrichmentStatus != "" {
+		q.where(eq("enrichment_status", filter.EnrichmentStatus))
+	}
+	if filter.MinGenderProbability > 0 {
+		q.where(gte("gender_probability", filter.MinGenderProbability))
+	}
+	if filter.MinNationalityProbability > 0 {
+		q.where(gte("nationality_probability", filter.MinNationalityProbability))
+	}
+	return q
+}
 
-	// Пагинация
-	query += fmt.Sprintf(" LIMIT %d OFFSET %d", limit, (page-1)*limit)
+// CountPersons возвращает число людей, подходящих под фильтр. При estimate число берется
+// из оценки планировщика PostgreSQL по статистике таблицы и не требует чтения строк.
+func (r *PersonRepositoryPgSQL) CountPersons(filter model.PersonFilter, estimate bool) (int, error) {
+	q := personFilterQuery(filter)
+	if !estimate {
+		var total int
+		err := r.db.QueryRow("SELECT count(*) FROM persons"+q.whereSQL(), q.args...).Scan(&total)
+		return total, mapError(err)
+	}
+
+	var plan []byte
+	if err := r.db.QueryRow("EXPLAIN (FORMAT JSON) SELECT 1 FROM persons"+q.whereSQL(), q.args...).Scan(&plan); err != nil {
+		return 0, mapError(err)
+	}
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil || len(explained) == 0 {
+		return 0, fmt.Errorf("не удалось разобрать план запроса: %v", err)
+	}
+	return int(explained[0].Plan.Rows), nil
+}
 
-	rows, err := r.db.Query(query)
+// reenrichAttributeColumns столбцы значений и источников атрибутов для отбора на повторное обогащение
+var reenrichAttributeColumns = map[string][2]string{
+	"age":         {"age", "age_source"},
+	"gender":      {"gender", "gender_source"},
+	"nationality": {"nationality", "nationality_source"},
+}
+
+// GetPersonsForReenrichment возвращает до limit людей с id больше afterID, подходящих под фильтр, по возрастанию id
+func (r *PersonRepositoryPgSQL) GetPersonsForReenrichment(filter model.ReenrichFilter, afterID, limit int) ([]model.Person, error) {
+	q := (&query{}).where(gt("id", afterID))
+	if filter.FromID > 0 {
+		q.where(gte("id", filter.FromID))
+	}
+	if filter.ToID > 0 {
+		q.where(lte("id", filter.ToID))
+	}
+	if len(filter.Missing) > 0 {
+		var missing []condition
+		for _, attr := range filter.Missing {
+			if columns, ok := reenrichAttributeColumns[attr]; ok {
+				missing = append(missing, isNull(columns[0]))
+			}
+		}
+		q.where(or(missing...))
+	}
+	if len(filter.Sources) > 0 {
+		var bySource []condition
+		for _, attr := range []string{"age", "gender", "nationality"} {
+			bySource = append(bySource, in(reenrichAttributeColumns[attr][1], filter.Sources))
+		}
+		q.where(or(bySource...))
+	}
+	if !filter.EnrichedBefore.IsZero() {
+		q.where(or(isNull("enriched_at"), lt("enriched_at", filter.EnrichedBefore)))
+	}
+
+	stmt := "SELECT " + personColumns + " FROM persons" + q.whereSQL() + " ORDER BY id LIMIT " + q.arg(limit)
+	return r.queryPersons(stmt, q.args)
+}
+
+// GetPersonsWithoutNormalizedName возвращает до limit людей с id больше afterID, у которых
+// не заполнено нормализованное имя, по возрастанию id
+func (r *PersonRepositoryPgSQL) GetPersonsWithoutNormalizedName(afterID, limit int) ([]model.Person, error) {
+	q := (&query{}).where(gt("id", afterID), isNull("normalized_name"))
+	stmt := "SELECT " + personColumns + " FROM persons" + q.whereSQL() + " ORDER BY id LIMIT " + q.arg(limit)
+	return r.queryPersons(stmt, q.args)
+}
+
+// SetNormalizedName заполняет нормализованное имя, если оно еще не задано. Имя возвращается
+// вместе с человеком, поэтому версия увеличивается, как при любом изменении.
+func (r *PersonRepositoryPgSQL) SetNormalizedName(id int, normalizedName string) error {
+	_, err := r.db.Exec(`UPDATE persons SET normalized_name=$1, version=version+1
+		WHERE id=$2 AND normalized_name IS NULL AND $1 <> ''`,
+		normalizedName, id)
+	return mapError(err)
+}
+
+// queryPersons выполняет запрос, выбирающий personColumns, и читает людей из результата
+func (r *PersonRepositoryPgSQL) queryPersons(query string, args []any) ([]model.Person, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		slog.Error("Ошибка выполнения запроса: ", err)
-		return nil, err
+		slog.Error("Ошибка выполнения запроса", "error", err)
+		return nil, mapError(err)
 	}
 	defer rows.Close()
 
+	people := []model.Person{}
 	for rows.Next() {
-		var person model.Person
-		if err := rows.Scan(&person.ID, &person.Name, &person.Surname, &person.Patronymic, &person.Age, &person.Gender, &person.Nationality); err != nil {
-			slog.Error("Ошибка при сканировании строки: ", err)
+		person, err := scanPerson(rows)
+		if err != nil {
+			slog.Error("Ошибка при сканировании строки", "error", err)
 			return nil, err
 		}
 		people = append(people, person)
 	}
 
 	if err := rows.Err(); err != nil {
-		slog.Error("Ошибка при обработке строк: ", err)
+		slog.Error("Ошибка при обработке строк", "error", err)
 		return nil, err
 	}
 
 	return people, nil
 }
+
+// scanPerson читает человека из строки результата, выбранной по personColumns
+func scanPerson(row interface{ Scan(dest ...any) error }) (model.Person, error) {
+	var p model.Person
+	var nationalities []byte
+	err := row.Scan(&p.ID, &p.Name, &p.NormalizedName, &p.Surname, &p.Patronymic,
+		&p.Age, &p.AgeCount, &p.AgeSource,
+		&p.Gender, &p.GenderProbability, &p.GenderSource,
+		&p.Nationality, &p.NationalityProbability, &nationalities, &p.NationalitySource,
+		pq.Array(&p.LowConfidence), &p.EnrichmentStatus, &p.Version)
+	if err != nil {
+		return p, err
+	}
+	if len(nationalities) > 0 {
+		err = json.Unmarshal(nationalities, &p.Nationalities)
+	}
+	return p, err
+}
+
+// marshalNationalities кодирует распределение национальностей для столбца JSONB; пустое распределение хранится как NULL
+func marshalNationalities(countries []model.CountryPrediction) (*string, error) {
+	if len(countries) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(countries)
+	if err != nil {
+		return nil, err
+	}
+	value := string(data)
+	return &value, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Статусы фонового повторного обогащения
+const (
+	ReenrichRunning = "running"
+	ReenrichDone    = "done"
+	ReenrichFailed  = "failed"
+)
+
+// Число хранимых завершенных запусков; более старые удаляются, выполняющиеся не удаляются никогда
+const maxFinishedRuns = 100
+
+// ReenrichRun фоновый запуск повторного обогащения
+type ReenrichRun struct {
+	ID         int             `json:"id"`
+	Status     string          `json:"status"`
+	Options    ReenrichOptions `json:"options"`
+	Report     ReenrichReport  `json:"report"` // Промежуточный итог, пока запуск выполняется
+	Error      string          `json:"error,omitempty"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Интерфейс сервиса для служебных операций
+type AdminService interface {
+	CacheStats() CacheStats
+	QuotaStates() []QuotaState
+	StartReenrichment(opts ReenrichOptions) (ReenrichRun, error)
+	GetReenrichment(id int) (ReenrichRun, bool)
+}
+
+// Реализация сервиса для служебных операций
+type AdminServiceImpl struct {
+	cache      *CachingEnricher
+	quotas     []*QuotaTracker
+	reenricher *Reenricher
+
+	mu     sync.Mutex
+	runs   map[int]*ReenrichRun
+	lastID int
+}
+
+// Конструктор для создания сервиса служебных операций
+func NewAdminService(cache *CachingEnricher, quotas []*QuotaTracker, reenricher *Reenricher) *AdminServiceImpl {
+	return &AdminServiceImpl{cache: cache, quotas: quotas, reenricher: reenricher, runs: make(map[int]*ReenrichRun)}
+}
+
+// Статистика кеша обогащения
+func (s *AdminServiceImpl) CacheStats() CacheStats {
+	return s.cache.Stats()
+}
+
+// Остаток квот внешних API
+func (s *AdminServiceImpl) QuotaStates() []QuotaState {
+	states := make([]QuotaState, 0, len(s.quotas))
+	for _, quota := range s.quotas {
+		states = append(states, quota.State())
+	}
+	return states
+}
+
+// Запуск повторного обогащения в фоне; запуски хранятся в памяти, из завершенных остаются последние maxFinishedRuns
+func (s *AdminServiceImpl) StartReenrichment(opts ReenrichOptions) (ReenrichRun, error) {
+	if err := ValidateReenrichFilter(opts.Filter); err != nil {
+		return ReenrichRun{}, err
+	}
+
+	s.mu.Lock()
+	s.lastID++
+	run := &ReenrichRun{
+		ID:        s.lastID,
+		Status:    ReenrichRunning,
+		Options:   opts,
+		Report:    ReenrichReport{DryRun: opts.DryRun, Results: []ReenrichResult{}},
+		StartedAt: time.Now(),
+	}
+	s.runs[run.ID] = run
+	snapshot := *run
+	s.mu.Unlock()
+
+	go func() {
+		report, err := s.reenricher.Run(context.Background(), opts, func(report ReenrichReport) {
+			s.mu.Lock()
+			run.Report = report
+			s.mu.Unlock()
+		})
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		finished := time.Now()
+		run.Report, run.FinishedAt, run.Status = report, &finished, ReenrichDone
+		if err != nil {
+			slog.Error("Ошибка повторного обогащения", "run", run.ID, "error", err)
+			run.Status, run.Error = ReenrichFailed, err.Error()
+		}
+		s.trimRuns()
+	}()
+	return snapshot, nil
+}
+
+// trimRuns удаляет самые старые завершенные запуски сверх maxFinishedRuns; вызывается под s.mu
+func (s *AdminServiceImpl) trimRuns() {
+	var finished []int
+	for id, run := range s.runs {
+		if run.Status != ReenrichRunning {
+			finished = append(finished, id)
+		}
+	}
+	if len(finished) <= maxFinishedRuns {
+		return
+	}
+	slices.Sort(finished)
+	for _, id := range finished[:len(finished)-maxFinishedRuns] {
+		delete(s.runs, id)
+	}
+}
+
+// Состояние запуска повторного обогащения
+func (s *AdminServiceImpl) GetReenrichment(id int) (ReenrichRun, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.runs[id]
+	if !ok {
+		return ReenrichRun{}, false
+	}
+	return *run, true
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/config"
+	"testing"
+	"time"
+)
+
+func TestStartReenrichmentTrimsRuns(t *testing.T) {
+	s := NewAdminService(nil, nil, NewReenricher(&reenrichRepo{}, &countingEnricher{}, &config.EnrichmentConfig{}))
+
+	total := maxFinishedRuns + 20
+	for range total {
+		if _, err := s.StartReenrichment(ReenrichOptions{DryRun: true}); err != nil {
+			t.Fatalf("StartReenrichment() error = %v", err)
+		}
+	}
+	// Запуски по пустой таблице завершаются сразу; ждем, пока число хранимых не упадет до предела
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		kept := len(s.runs)
+		s.mu.Unlock()
+		if kept == maxFinishedRuns {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("хранится запусков: %d, want %d", kept, maxFinishedRuns)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for id := 1; id <= total-maxFinishedRuns; id++ {
+		if _, ok := s.GetReenrichment(id); ok {
+			t.Errorf("запуск %d не удален", id)
+		}
+	}
+	for id := total - maxFinishedRuns + 1; id <= total; id++ {
+		if run, ok := s.GetReenrichment(id); !ok || run.Status != ReenrichDone {
+			t.Errorf("запуск %d = %+v, %v, want завершенный", id, run, ok)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/repository"
+	"context"
+	"log/slog"
+)
+
+// backfillPageSize число людей, которые читаются из БД за один запрос при заполнении нормализованных имен
+const backfillPageSize = 500
+
+// BackfillNormalizedNames заполняет нормализованные имена людей, сохраненных до их появления:
+// миграция заполняет только имена в латинице, транслитерация кириллицы выполняется здесь.
+// Как и любое изменение, заполнение увеличивает версию человека и меняет его ETag.
+// Возвращает число обновленных людей.
+func BackfillNormalizedNames(ctx context.Context, repo repository.PersonRepository) (int, error) {
+	updated, afterID := 0, 0
+	for ctx.Err() == nil {
+		persons, err := repo.GetPersonsWithoutNormalizedName(afterID, backfillPageSize)
+		if err != nil {
+			return updated, err
+		}
+		if len(persons) == 0 {
+			break
+		}
+		for _, person := range persons {
+			normalized := NormalizeName(person.Name)
+			if normalized == "" {
+				continue
+			}
+			if err = repo.SetNormalizedName(person.ID, normalized); err != nil {
+				return updated, err
+			}
+			updated++
+		}
+		afterID = persons[len(persons)-1].ID
+	}
+	if updated > 0 {
+		slog.Info("Заполнены нормализованные имена", "count", updated)
+	}
+	return updated, ctx.Err()
+}
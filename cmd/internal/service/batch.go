@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// maxBatchSize ограничение внешних API на число имен name[] в одном запросе
+const maxBatchSize = 10
+
+// batchConcurrency ограничивает число одновременных пакетных запросов к внешним API
+const batchConcurrency = 4
+
+// BatchAgeProvider определяет возраст сразу для нескольких имен (не более maxBatchSize).
+// Результаты возвращаются в порядке имен.
+type BatchAgeProvider interface {
+	AgeBatch(ctx context.Context, names []string, countryID string) ([]AgeResult, error)
+}
+
+// BatchGenderProvider определяет пол сразу для нескольких имен (не более maxBatchSize)
+type BatchGenderProvider interface {
+	GenderBatch(ctx context.Context, names []string, countryID string) ([]GenderResult, error)
+}
+
+// BatchNationalityProvider определяет национальности сразу для нескольких имен (не более maxBatchSize)
+type BatchNationalityProvider interface {
+	NationalityBatch(ctx context.Context, names []string) ([]NationalityResult, error)
+}
+
+// BatchEnricher обогащает пачку запросов. Результаты и ошибки возвращаются в порядке запросов,
+// ошибка одного запроса не мешает остальным.
+type BatchEnricher interface {
+	EnrichBatch(ctx context.Context, reqs []EnrichRequest) ([]Enrichment, []error)
+}
+
+// enrichBatch обогащает пачку запросов одним вызовом, если обогатитель поддерживает пакетный режим, иначе по одному
+func enrichBatch(ctx context.Context, enricher Enricher, reqs []EnrichRequest) ([]Enrichment, []error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	if batch, ok := enricher.(BatchEnricher); ok {
+		return batch.EnrichBatch(ctx, reqs)
+	}
+
+	results := make([]Enrichment, len(reqs))
+	errs := make([]error, len(reqs))
+	for i, req := range reqs {
+		results[i], errs[i] = enricher.Enrich(ctx, req)
+	}
+	return results, errs
+}
+
+// batchGroup имена, для которых один атрибут запрашивается с одной и той же страной
+type batchGroup struct {
+	attr      string
+	countryID string
+	names     []string         // Уникальные имена в порядке появления
+	targets   map[string][]int // Индексы запросов для каждого имени
+}
+
+// groupBatch группирует запросы по атрибуту и стране, убирая повторяющиеся имена
+func groupBatch(reqs []EnrichRequest) []*batchGroup {
+	var groups []*batchGroup
+	index := make(map[[2]string]*batchGroup)
+	for i, req := range reqs {
+		name := strings.TrimSpace(req.Name)
+		for _, attr := range allAttributes {
+			if !req.wants(attr) {
+				continue
+			}
+			countryID := req.CountryID
+			if attr == AttributeNationality {
+				countryID = ""
+			}
+			group, ok := index[[2]string{attr, countryID}]
+			if !ok {
+				group = &batchGroup{attr: attr, countryID: countryID, targets: make(map[string][]int)}
+				index[[2]string{attr, countryID}] = group
+				groups = append(groups, group)
+			}
+			key := strings.ToLower(name)
+			if _, seen := group.targets[key]; !seen {
+				group.names = append(group.names, name)
+			}
+			group.targets[key] = append(group.targets[key], i)
+		}
+	}
+	return groups
+}
+
+// EnrichBatch реализует интерфейс BatchEnricher. Одинаковые имена запрашиваются один раз,
+// имена отправляются пачками по maxBatchSize тем провайдерам, которые это поддерживают.
+func (e *CompositeEnricher) EnrichBatch(ctx context.Context, reqs []EnrichRequest) ([]Enrichment, []error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, batchConcurrency)
+		results = make([]Enrichment, len(reqs))
+		failed  = make([]map[string]error, len(reqs))
+	)
+
+	for _, group := range groupBatch(reqs) {
+		for start := 0; start < len(group.names); start += maxBatchSize {
+			chunk := group.names[start:min(start+maxBatchSize, len(group.names))]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				values, errs := e.lookupBatch(ctx, group.attr, group.countryID, chunk)
+				<-sem
+
+				mu.Lock()
+				defer mu.Unlock()
+				for j, name := range chunk {
+					for _, i := range group.targets[strings.ToLower(name)] {
+						if errs[j] != nil {
+							if failed[i] == nil {
+								failed[i] = make(map[string]error)
+							}
+							failed[i][group.attr] = errs[j]
+							continue
+						}
+						results[i].merge(values[j])
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	errs := make([]error, len(reqs))
+	for i, attrErrs := range failed {
+		if len(attrErrs) > 0 {
+			errs[i] = &EnrichmentError{Name: reqs[i].Name, Failed: attrErrs}
+		}
+	}
+	return results, errs
+}
+
+// lookupBatch запрашивает один атрибут для пачки имен
+func (e *CompositeEnricher) lookupBatch(ctx context.Context, attr, countryID string, names []string) ([]Enrichment, []error) {
+	switch attr {
+	case AttributeAge:
+		var batch func([]string) ([]AgeResult, error)
+		if p, ok := e.age.(BatchAgeProvider); ok {
+			batch = func(names []string) ([]AgeResult, error) { return p.AgeBatch(ctx, names, countryID) }
+		}
+		single := func(name string) (AgeResult, error) { return e.age.Age(ctx, name, countryID) }
+		return lookupEach(names, batch, single, (*Enrichment).setAge)
+	case AttributeGender:
+		var batch func([]string) ([]GenderResult, error)
+		if p, ok := e.gender.(BatchGenderProvider); ok {
+			batch = func(names []string) ([]GenderResult, error) { return p.GenderBatch(ctx, names, countryID) }
+		}
+		single := func(name string) (GenderResult, error) { return e.gender.Gender(ctx, name, countryID) }
+		return lookupEach(names, batch, single, (*Enrichment).setGender)
+	default:
+		var batch func([]string) ([]NationalityResult, error)
+		if p, ok := e.nationality.(BatchNationalityProvider); ok {
+			batch = func(names []string) ([]NationalityResult, error) { return p.NationalityBatch(ctx, names) }
+		}
+		single := func(name string) (NationalityResult, error) { return e.nationality.Nationality(ctx, name) }
+		return lookupEach(names, batch, single, (*Enrichment).setNationality)
+	}
+}
+
+// lookupEach получает результаты пакетным запросом batch, а если он не поддерживается, по одному через single
+func lookupEach[T any](names []string, batch func([]string) ([]T, error), single func(string) (T, error), set func(*Enrichment, T)) ([]Enrichment, []error) {
+	values := make([]Enrichment, len(names))
+	errs := make([]error, len(names))
+
+	if batch != nil {
+		results, err := batch(names)
+		for i := range names {
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			set(&values[i], results[i])
+		}
+		return values, errs
+	}
+
+	for i, name := range names {
+		result, err := single(name)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		set(&values[i], result)
+	}
+	return values, errs
+}
+
+// EnrichBatch реализует интерфейс BatchEnricher: внутреннему обогатителю одной пачкой
+// передаются только запросы, которых нет в кеше, и только недостающие атрибуты
+func (e *CachingEnricher) EnrichBatch(ctx context.Context, reqs []EnrichRequest) ([]Enrichment, []error) {
+	results := make([]Enrichment, len(reqs))
+	errs := make([]error, len(reqs))
+	cached := make([]Enrichment, len(reqs))
+
+	var fetch []EnrichRequest
+	var pending []int
+	for i, req := range reqs {
+		var found bool
+		cached[i], found = e.lookup(cacheKey(req.Name, req.CountryID))
+
+		missing := req
+		if !cacheBypassed(ctx) {
+			if found && cached[i].covers(req) {
+				e.hits.Add(1)
+				results[i] = cached[i].only(req)
+				continue
+			}
+			e.misses.Add(1)
+			missing.Attributes = cached[i].missing(req)
+		}
+		fetch = append(fetch, missing)
+		pending = append(pending, i)
+	}
+
+	values, fetchErrs := enrichBatch(ctx, e.inner, fetch)
+	for j, i := range pending {
+		e.store(cacheKey(reqs[i].Name, reqs[i].CountryID), &cached[i], values[j])
+		results[i], errs[i] = cached[i].only(reqs[i]), fetchErrs[j]
+	}
+	return results, errs
+}
+
+// EnrichBatch реализует интерфейс BatchEnricher: национальности всех запросов без страны
+// определяются одной пачкой, затем так же пачкой запрашиваются возраст и пол
+func (e *TwoPassEnricher) EnrichBatch(ctx context.Context, reqs []EnrichRequest) ([]Enrichment, []error) {
+	results := make([]Enrichment, len(reqs))
+	errs := make([]error, len(reqs))
+
+	var direct, first []EnrichRequest
+	var directIdx, twoPassIdx []int
+	for i, req := range reqs {
+		if needsTwoPass(req) {
+			first = append(first, nationalityRequest(req))
+			twoPassIdx = append(twoPassIdx, i)
+			continue
+		}
+		direct = append(direct, req)
+		directIdx = append(directIdx, i)
+	}
+
+	values, directErrs := enrichBatch(ctx, e.inner, direct)
+	for j, i := range directIdx {
+		results[i], errs[i] = values[j], directErrs[j]
+	}
+
+	nationalities, nationErrs := enrichBatch(ctx, e.inner, first)
+	second := make([]EnrichRequest, len(twoPassIdx))
+	for j, i := range twoPassIdx {
+		second[j] = localizedRequest(reqs[i], nationalities[j].Nationality)
+	}
+	localized, localizedErrs := enrichBatch(ctx, e.inner, second)
+	for j, i := range twoPassIdx {
+		nationalities[j].merge(localized[j])
+		results[i] = nationalities[j]
+		errs[i] = joinEnrichmentErrors(reqs[i].Name, nationErrs[j], localizedErrs[j])
+	}
+	return results, errs
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// batchProvider пакетный провайдер всех атрибутов: возраст равен длине имени плюс 20,
+// пол и национальность постоянные. Пачка, в которой есть имя из fail, целиком завершается ошибкой.
+type batchProvider struct {
+	mu    sync.Mutex
+	fail  string
+	calls []string // Вызовы в виде attr@country:name1,name2
+}
+
+func (p *batchProvider) record(attr, countryID string, names []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, attr+"@"+countryID+":"+strings.Join(names, ","))
+	if slices.Contains(names, p.fail) {
+		return errors.New("провайдер недоступен")
+	}
+	return nil
+}
+
+func (p *batchProvider) Age(ctx context.Context, name, countryID string) (AgeResult, error) {
+	results, err := p.AgeBatch(ctx, []string{name}, countryID)
+	if err != nil {
+		return AgeResult{}, err
+	}
+	return results[0], nil
+}
+
+func (p *batchProvider) AgeBatch(ctx context.Context, names []string, countryID string) ([]AgeResult, error) {
+	if err := p.record(AttributeAge, countryID, names); err != nil {
+		return nil, err
+	}
+	results := make([]AgeResult, len(names))
+	for i, name := range names {
+		results[i] = AgeResult{Age: len(name) + 20, Count: 10, Source: model.SourceAgify}
+	}
+	return results, nil
+}
+
+func (p *batchProvider) Gender(ctx context.Context, name, countryID string) (GenderResult, error) {
+	results, err := p.GenderBatch(ctx, []string{name}, countryID)
+	if err != nil {
+		return GenderResult{}, err
+	}
+	return results[0], nil
+}
+
+func (p *batchProvider) GenderBatch(ctx context.Context, names []string, countryID string) ([]GenderResult, error) {
+	if err := p.record(AttributeGender, countryID, names); err != nil {
+		return nil, err
+	}
+	results := make([]GenderResult, len(names))
+	for i := range names {
+		results[i] = GenderResult{Gender: "male", Probability: 0.9, Source: model.SourceGenderize}
+	}
+	return results, nil
+}
+
+func (p *batchProvider) Nationality(ctx context.Context, name string) (NationalityResult, error) {
+	results, err := p.NationalityBatch(ctx, []string{name})
+	if err != nil {
+		return NationalityResult{}, err
+	}
+	return results[0], nil
+}
+
+func (p *batchProvider) NationalityBatch(ctx context.Context, names []string) ([]NationalityResult, error) {
+	if err := p.record(AttributeNationality, "", names); err != nil {
+		return nil, err
+	}
+	results := make([]NationalityResult, len(names))
+	for i := range names {
+		results[i] = NationalityResult{Countries: []model.CountryPrediction{{CountryID: "RU", Probability: 0.8}}, Source: model.SourceNationalize}
+	}
+	return results, nil
+}
+
+func TestGroupBatch(t *testing.T) {
+	reqs := []EnrichRequest{
+		{Name: "ivan", CountryID: "RU"},
+		{Name: " Ivan ", CountryID: "RU"},
+		{Name: "maria", CountryID: "RU", Attributes: []string{AttributeAge}},
+		{Name: "IVAN", CountryID: "KZ", Attributes: []string{AttributeGender, AttributeNationality}},
+		{Name: "petr", Attributes: []string{}},
+	}
+	want := []batchGroup{
+		{attr: AttributeAge, countryID: "RU", names: []string{"ivan", "maria"}, targets: map[string][]int{"ivan": {0, 1}, "maria": {2}}},
+		{attr: AttributeGender, countryID: "RU", names: []string{"ivan"}, targets: map[string][]int{"ivan": {0, 1}}},
+		// Национальность не зависит от страны, поэтому все ivan попадают в одну группу
+		{attr: AttributeNationality, names: []string{"ivan"}, targets: map[string][]int{"ivan": {0, 1, 3}}},
+		{attr: AttributeGender, countryID: "KZ", names: []string{"IVAN"}, targets: map[string][]int{"ivan": {3}}},
+	}
+
+	groups := groupBatch(reqs)
+	got := make([]batchGroup, len(groups))
+	for i, group := range groups {
+		got[i] = *group
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupBatch() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompositeEnricherBatch(t *testing.T) {
+	provider := &batchProvider{fail: "petr"}
+	enricher := NewCompositeEnricher(provider, provider, provider)
+	reqs := []EnrichRequest{
+		{Name: "ivan", CountryID: "RU"},
+		{Name: "maria", CountryID: "RU"},
+		{Name: "IVAN", CountryID: "RU"},
+		{Name: "petr", CountryID: "KZ", Attributes: []string{AttributeAge}},
+		{Name: "maria", CountryID: "RU", Attributes: []string{AttributeGender}},
+	}
+
+	results, errs := enricher.EnrichBatch(context.Background(), reqs)
+
+	calls := slices.Clone(provider.calls)
+	slices.Sort(calls)
+	wantCalls := []string{"age@KZ:petr", "age@RU:ivan,maria", "gender@RU:ivan,maria", "nationality@:ivan,maria"}
+	if !slices.Equal(calls, wantCalls) {
+		t.Errorf("вызовы = %v, want %v", calls, wantCalls)
+	}
+
+	tests := []struct {
+		name       string
+		i          int
+		wantAge    int
+		wantKnown  []string
+		wantFailed []string
+	}{
+		{name: "ivan", i: 0, wantAge: 24, wantKnown: allAttributes},
+		{name: "maria", i: 1, wantAge: 25, wantKnown: allAttributes},
+		{name: "повторное имя получает тот же результат", i: 2, wantAge: 24, wantKnown: allAttributes},
+		{name: "ошибка пачки относится только к ее запросам", i: 3, wantFailed: []string{AttributeAge}},
+		{name: "только запрошенные атрибуты", i: 4, wantKnown: []string{AttributeGender}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := results[tt.i]
+			if got.Age != tt.wantAge {
+				t.Errorf("Age = %d, want %d", got.Age, tt.wantAge)
+			}
+			for _, attr := range allAttributes {
+				if known := got.known(attr); known != slices.Contains(tt.wantKnown, attr) {
+					t.Errorf("known(%s) = %v, want %v", attr, known, !known)
+				}
+			}
+			var enrichErr *EnrichmentError
+			switch {
+			case tt.wantFailed == nil && errs[tt.i] != nil:
+				t.Errorf("error = %v, want nil", errs[tt.i])
+			case tt.wantFailed != nil && (!errors.As(errs[tt.i], &enrichErr) || !slices.Equal(enrichErr.Attributes(), tt.wantFailed)):
+				t.Errorf("error = %v, want failed %v", errs[tt.i], tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestCompositeEnricherBatchChunks(t *testing.T) {
+	provider := &batchProvider{}
+	enricher := NewCompositeEnricher(provider, provider, provider)
+	reqs := make([]EnrichRequest, 23)
+	for i := range reqs {
+		reqs[i] = EnrichRequest{Name: fmt.Sprintf("name%02d", i), Attributes: []string{AttributeAge}}
+	}
+
+	results, _ := enricher.EnrichBatch(context.Background(), reqs)
+
+	var sizes []int
+	for _, call := range provider.calls {
+		sizes = append(sizes, strings.Count(call, ",")+1)
+	}
+	slices.Sort(sizes)
+	if want := []int{3, maxBatchSize, maxBatchSize}; !slices.Equal(sizes, want) {
+		t.Errorf("размеры пачек = %v, want %v", sizes, want)
+	}
+	for i, result := range results {
+		if result.Age != len(reqs[i].Name)+20 {
+			t.Errorf("results[%d].Age = %d, want %d", i, result.Age, len(reqs[i].Name)+20)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/repository"
+	"container/list"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EnrichmentCache хранит результаты обогащения по нормализованному имени
+type EnrichmentCache interface {
+	Get(key string) (Enrichment, bool)
+	Set(key string, value Enrichment)
+}
+
+// CacheStats содержит счетчики обращений к кешу обогащения
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass возвращает контекст, в котором обогащение не читает кеш.
+// Свежий результат при этом все равно сохраняется в кеш.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+// cacheVersion меняется вместе с форматом Enrichment, чтобы не читать устаревшие записи
+const cacheVersion = "v2"
+
+// cacheKey приводит имя и страну к виду, по которому ищется результат в кеше
+func cacheKey(name, countryID string) string {
+	key := cacheVersion + ":" + strings.ToLower(strings.TrimSpace(name))
+	if countryID != "" {
+		key += "@" + strings.ToLower(countryID)
+	}
+	return key
+}
+
+// CachingEnricher оборачивает обогатитель многоуровневым кешем.
+// Кеши опрашиваются по порядку, найденное значение дописывается в предыдущие уровни.
+type CachingEnricher struct {
+	inner  Enricher
+	caches []EnrichmentCache
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewCachingEnricher создает обогатитель с кешированием результатов
+func NewCachingEnricher(inner Enricher, caches ...EnrichmentCache) *CachingEnricher {
+	return &CachingEnricher{inner: inner, caches: caches}
+}
+
+// Enrich реализует интерфейс Enricher. У внутреннего обогатителя запрашиваются
+// только атрибуты, которых нет в кеше; полученные атрибуты дописываются в кеш,
+// даже если остальные получить не удалось.
+func (e *CachingEnricher) Enrich(ctx context.Context, req EnrichRequest) (Enrichment, error) {
+	key := cacheKey(req.Name, req.CountryID)
+	cached, found := e.lookup(key)
+
+	fetch := req
+	if !cacheBypassed(ctx) {
+		if found && cached.covers(req) {
+			e.hits.Add(1)
+			slog.Debug("Результат обогащения найден в кеше", "name", req.Name)
+			return cached.only(req), nil
+		}
+		e.misses.Add(1)
+		fetch.Attributes = cached.missing(req)
+	}
+
+	value, err := e.inner.Enrich(ctx, fetch)
+	e.store(key, &cached, value)
+	return cached.only(req), err
+}
+
+// store дополняет запись кеша полученными атрибутами и сохраняет ее во все уровни
+func (e *CachingEnricher) store(key string, cached *Enrichment, value Enrichment) {
+	cached.merge(value)
+	if value.has(AttributeAge) || value.has(AttributeGender) || value.has(AttributeNationality) {
+		for _, cache := range e.caches {
+			cache.Set(key, *cached)
+		}
+	}
+}
+
+// lookup ищет запись во всех уровнях кеша и дописывает найденное в предыдущие уровни
+func (e *CachingEnricher) lookup(key string) (Enrichment, bool) {
+	for i, cache := range e.caches {
+		value, ok := cache.Get(key)
+		if !ok {
+			continue
+		}
+		for _, upper := range e.caches[:i] {
+			upper.Set(key, value)
+		}
+		return value, true
+	}
+	return Enrichment{}, false
+}
+
+// Stats возвращает счетчики попаданий и промахов
+func (e *CachingEnricher) Stats() CacheStats {
+	return CacheStats{Hits: e.hits.Load(), Misses: e.misses.Load()}
+}
+
+// LRUCache хранит ограниченное число записей в памяти с временем жизни
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List
+	items    map[string]*list.Element
+	now      func() time.Time // Часы; подменяются в тестах
+}
+
+type lruEntry struct {
+	key       string
+	value     Enrichment
+	expiresAt time.Time
+}
+
+// NewLRUCache создает кеш в памяти на capacity записей
+func NewLRUCache(capacity int, ttl time.Duration) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Get реализует интерфейс EnrichmentCache
+func (c *LRUCache) Get(key string) (Enrichment, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return Enrichment{}, false
+	}
+	entry := el.Value.(*lruEntry)
+	if c.now().After(entry.expiresAt) {
+		c.order.Remove(el)
+		delete(c.items, key)
+		return Enrichment{}, false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// Set реализует интерфейс EnrichmentCache
+func (c *LRUCache) Set(key string, value Enrichment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// PersistentCache хранит результаты обогащения в PostgreSQL и переживает перезапуски
+type PersistentCache struct {
+	repo repository.EnrichmentCacheRepository
+	ttl  time.Duration
+}
+
+// NewPersistentCache создает кеш поверх репозитория
+func NewPersistentCache(repo repository.EnrichmentCacheRepository, ttl time.Duration) *PersistentCache {
+	return &PersistentCache{repo: repo, ttl: ttl}
+}
+
+// Get реализует интерфейс EnrichmentCache. Ошибки БД считаются промахом.
+func (c *PersistentCache) Get(key string) (Enrichment, bool) {
+	payload, ok, err := c.repo.GetCachedEnrichment(key, c.ttl)
+	if err != nil {
+		slog.Error("Ошибка чтения кеша обогащения", "key", key, "error", err)
+		return Enrichment{}, false
+	}
+	if !ok {
+		return Enrichment{}, false
+	}
+
+	var value Enrichment
+	if err = json.Unmarshal(payload, &value); err != nil {
+		slog.Error("Ошибка декодирования записи кеша обогащения", "key", key, "error", err)
+		return Enrichment{}, false
+	}
+	return value, true
+}
+
+// Set реализует интерфейс EnrichmentCache
+func (c *PersistentCache) Set(key string, value Enrichment) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		slog.Error("Ошибка кодирования записи кеша обогащения", "key", key, "error", err)
+		return
+	}
+	if err = c.repo.SaveCachedEnrichment(key, payload); err != nil {
+		slog.Error("Ошибка записи кеша обогащения", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock часы, которые двигаются только вызовом Advance
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// countingEnricher отвечает данными value и запоминает запросы.
+// Атрибуты из fail не возвращаются и попадают в EnrichmentError.
+type countingEnricher struct {
+	mu    sync.Mutex
+	value Enrichment
+	fail  []string
+	reqs  []EnrichRequest
+}
+
+func (e *countingEnricher) Enrich(ctx context.Context, req EnrichRequest) (Enrichment, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.reqs = append(e.reqs, req)
+
+	result := e.value.only(req)
+	failed := map[string]error{}
+	for _, attr := range e.fail {
+		if req.wants(attr) {
+			result.clear(attr)
+			failed[attr] = errors.New("провайдер недоступен")
+		}
+	}
+	if len(failed) > 0 {
+		return result, &EnrichmentError{Name: req.Name, Failed: failed}
+	}
+	return result, nil
+}
+
+// attributes возвращает атрибуты всех запросов к обогатителю по порядку
+func (e *countingEnricher) attributes() [][]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	attrs := make([][]string, len(e.reqs))
+	for i, req := range e.reqs {
+		attrs[i] = req.Attributes
+	}
+	return attrs
+}
+
+// testEnrichment возвращает данные, в которых известны все атрибуты
+func testEnrichment() Enrichment {
+	var e Enrichment
+	e.setAge(AgeResult{Age: 30, Count: 100, Source: model.SourceAgify})
+	e.setGender(GenderResult{Gender: "male", Probability: 0.9, Source: model.SourceGenderize})
+	e.setNationality(NationalityResult{
+		Countries: []model.CountryPrediction{{CountryID: "RU", Probability: 0.8}},
+		Source:    model.SourceNationalize,
+	})
+	return e
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	cache := NewLRUCache(2, time.Hour)
+	cache.Set("a", Enrichment{Age: 1})
+	cache.Set("b", Enrichment{Age: 2})
+	// Чтение делает a недавно использованной, поэтому вытесняется b
+	cache.Get("a")
+	cache.Set("c", Enrichment{Age: 3})
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := cache.Get(key); ok != want {
+			t.Errorf("Get(%q) found = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestLRUCacheTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		advance []time.Duration // Сдвиги часов; после первого запись перезаписывается
+		wantHit bool
+	}{
+		{name: "запись жива до истечения ttl", advance: []time.Duration{59 * time.Second}, wantHit: true},
+		{name: "запись истекает после ttl", advance: []time.Duration{61 * time.Second}},
+		{name: "перезапись продлевает ttl", advance: []time.Duration{40 * time.Second, 40 * time.Second}, wantHit: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			cache := NewLRUCache(10, time.Minute)
+			cache.now = clock.Now
+			cache.Set("ivan", Enrichment{Age: 30})
+			for i, d := range tt.advance {
+				if i > 0 {
+					cache.Set("ivan", Enrichment{Age: 30})
+				}
+				clock.Advance(d)
+			}
+
+			value, ok := cache.Get("ivan")
+			if ok != tt.wantHit {
+				t.Fatalf("Get() found = %v, want %v", ok, tt.wantHit)
+			}
+			if ok && value.Age != 30 {
+				t.Errorf("Age = %d, want 30", value.Age)
+			}
+			if !ok && cache.order.Len() != 0 {
+				t.Errorf("истекшая запись не удалена: %d записей", cache.order.Len())
+			}
+		})
+	}
+}
+
+func TestCachingEnricher(t *testing.T) {
+	all := []string{AttributeAge, AttributeGender, AttributeNationality}
+	unknownAge := testEnrichment()
+	unknownAge.setAge(AgeResult{Source: model.SourceAgify})
+
+	tests := []struct {
+		name          string
+		value         Enrichment
+		fail          []string
+		first, second EnrichRequest
+		bypass        bool
+		wantReqs      [][]string
+		wantHits      int64
+		wantMisses    int64
+		wantErr       bool
+	}{
+		{
+			name:     "повторный запрос из кеша",
+			value:    testEnrichment(),
+			first:    EnrichRequest{Name: "ivan"},
+			second:   EnrichRequest{Name: " IVAN "},
+			wantReqs: [][]string{all},
+			wantHits: 1, wantMisses: 1,
+		},
+		{
+			name:     "страна входит в ключ",
+			value:    testEnrichment(),
+			first:    EnrichRequest{Name: "ivan"},
+			second:   EnrichRequest{Name: "ivan", CountryID: "RU"},
+			wantReqs: [][]string{all, all},
+			wantHits: 0, wantMisses: 2,
+		},
+		{
+			name:     "неизвестное значение тоже кешируется",
+			value:    unknownAge,
+			first:    EnrichRequest{Name: "ivan"},
+			second:   EnrichRequest{Name: "ivan", Attributes: []string{AttributeAge}},
+			wantReqs: [][]string{all},
+			wantHits: 1, wantMisses: 1,
+		},
+		{
+			name:     "недостающие атрибуты дозапрашиваются",
+			value:    testEnrichment(),
+			first:    EnrichRequest{Name: "ivan", Attributes: []string{AttributeAge}},
+			second:   EnrichRequest{Name: "ivan"},
+			wantReqs: [][]string{{AttributeAge}, {AttributeGender, AttributeNationality}},
+			wantHits: 0, wantMisses: 2,
+		},
+		{
+			name:     "неполученный атрибут не кешируется",
+			value:    testEnrichment(),
+			fail:     []string{AttributeGender},
+			first:    EnrichRequest{Name: "ivan"},
+			second:   EnrichRequest{Name: "ivan"},
+			wantReqs: [][]string{all, {AttributeGender}},
+			wantHits: 0, wantMisses: 2,
+			wantErr: true,
+		},
+		{
+			name:     "обход кеша запрашивает все атрибуты",
+			value:    testEnrichment(),
+			first:    EnrichRequest{Name: "ivan"},
+			second:   EnrichRequest{Name: "ivan"},
+			bypass:   true,
+			wantReqs: [][]string{all, nil},
+			wantHits: 0, wantMisses: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &countingEnricher{value: tt.value, fail: tt.fail}
+			enricher := NewCachingEnricher(inner, NewLRUCache(10, time.Hour))
+
+			enricher.Enrich(context.Background(), tt.first)
+			ctx := context.Background()
+			if tt.bypass {
+				ctx = WithCacheBypass(ctx)
+			}
+			got, err := enricher.Enrich(ctx, tt.second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Enrich() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if reqs := inner.attributes(); !reflect.DeepEqual(reqs, tt.wantReqs) {
+				t.Errorf("запросы = %v, want %v", reqs, tt.wantReqs)
+			}
+			if stats := enricher.Stats(); stats.Hits != tt.wantHits || stats.Misses != tt.wantMisses {
+				t.Errorf("Stats() = %+v, want hits %d, misses %d", stats, tt.wantHits, tt.wantMisses)
+			}
+			want := tt.value.only(tt.second)
+			for _, attr := range tt.fail {
+				want.clear(attr)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Enrich() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestCachingEnricherFillsUpperLevels(t *testing.T) {
+	upper, lower := NewLRUCache(10, time.Hour), NewLRUCache(10, time.Hour)
+	lower.Set(cacheKey("ivan", ""), testEnrichment())
+	inner := &countingEnricher{value: testEnrichment()}
+
+	if _, err := NewCachingEnricher(inner, upper, lower).Enrich(context.Background(), EnrichRequest{Name: "ivan"}); err != nil {
+		t.Fatalf("Enrich() error = %v", err)
+	}
+	if len(inner.reqs) != 0 {
+		t.Errorf("запросы = %v, want none", inner.attributes())
+	}
+	if _, ok := upper.Get(cacheKey("ivan", "")); !ok {
+		t.Error("запись нижнего уровня не дописана в верхний")
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+)
+
+// ChainEnricher опрашивает обогатители по порядку: следующему передаются только атрибуты,
+// для которых предыдущие не дали значения. Например, сначала локальный справочник, затем внешние API.
+type ChainEnricher struct {
+	enrichers []Enricher
+}
+
+// NewChainEnricher создает цепочку обогатителей
+func NewChainEnricher(enrichers ...Enricher) *ChainEnricher {
+	return &ChainEnricher{enrichers: enrichers}
+}
+
+// Enrich реализует интерфейс Enricher
+func (e *ChainEnricher) Enrich(ctx context.Context, req EnrichRequest) (Enrichment, error) {
+	results, errs := e.EnrichBatch(ctx, []EnrichRequest{req})
+	return results[0], errs[0]
+}
+
+// EnrichBatch реализует интерфейс BatchEnricher. Ошибка атрибута возвращается,
+// только если значение не дал ни один обогатитель цепочки.
+func (e *ChainEnricher) EnrichBatch(ctx context.Context, reqs []EnrichRequest) ([]Enrichment, []error) {
+	results := make([]Enrichment, len(reqs))
+	failed := make([]map[string]error, len(reqs))
+
+	pending := make([]int, len(reqs))
+	for i := range reqs {
+		pending[i] = i
+		failed[i] = make(map[string]error)
+	}
+
+	for _, enricher := range e.enrichers {
+		if len(pending) == 0 {
+			break
+		}
+		stage := make([]EnrichRequest, len(pending))
+		for j, i := range pending {
+			stage[j] = reqs[i]
+			stage[j].Attributes = results[i].unknown(reqs[i])
+		}
+
+		values, errs := enrichBatch(ctx, enricher, stage)
+		var next []int
+		for j, i := range pending {
+			results[i].merge(values[j])
+			recordFailures(failed[i], stage[j], values[j], errs[j])
+			if len(results[i].unknown(reqs[i])) > 0 {
+				next = append(next, i)
+			}
+		}
+		pending = next
+	}
+
+	errs := make([]error, len(reqs))
+	for i := range reqs {
+		if len(failed[i]) > 0 {
+			errs[i] = &EnrichmentError{Name: reqs[i].Name, Failed: failed[i]}
+		}
+	}
+	return results, errs
+}
+
+// unknown возвращает запрошенные атрибуты, для которых еще нет непустого значения
+func (e Enrichment) unknown(req EnrichRequest) []string {
+	attrs := []string{}
+	for _, attr := range allAttributes {
+		if req.wants(attr) && !e.known(attr) {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// recordFailures запоминает ошибки атрибутов одного этапа цепочки и снимает их с атрибутов, полученных на нем
+func recordFailures(failed map[string]error, req EnrichRequest, value Enrichment, err error) {
+	var enrichErr *EnrichmentError
+	for _, attr := range req.Attributes {
+		switch {
+		case value.has(attr):
+			delete(failed, attr)
+		case errors.As(err, &enrichErr) && enrichErr.Failed[attr] != nil:
+			failed[attr] = enrichErr.Failed[attr]
+		case err != nil && !errors.As(err, &enrichErr):
+			failed[attr] = err
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/config"
+	"TestEffectiveMobile/cmd/internal/model"
+	"errors"
+)
+
+// assessConfidence сверяет данные обогащения с порогами уверенности из конфигурации.
+// Значения ниже порога отбрасываются вместе с достоверностью и источником или остаются с пометкой,
+// в зависимости от режима.
+// Атрибуты, которые не удалось получить из-за err, попадают в список failed.
+// Атрибуты, не входящие в req, считаются переданными клиентом.
+func assessConfidence(cfg *config.EnrichmentConfig, e *Enrichment, req EnrichRequest, err error) model.EnrichmentReport {
+	report := newEnrichmentReport()
+
+	var enrichErr *EnrichmentError
+	failed := func(attr string) bool {
+		if errors.As(err, &enrichErr) {
+			_, ok := enrichErr.Failed[attr]
+			return ok
+		}
+		return err != nil
+	}
+
+	check := func(attr string, present, confident bool) {
+		switch {
+		case !req.wants(attr):
+			report.Provided = append(report.Provided, attr)
+		case failed(attr):
+			report.Failed = append(report.Failed, attr)
+		case !present:
+			report.Rejected = append(report.Rejected, attr)
+		case confident:
+			report.Accepted = append(report.Accepted, attr)
+		case cfg.LowConfidenceMode == config.LowConfidenceFlag:
+			report.Flagged = append(report.Flagged, attr)
+		default:
+			e.clear(attr)
+			report.Rejected = append(report.Rejected, attr)
+		}
+	}
+
+	check(AttributeAge, e.Age > 0, e.AgeCount >= cfg.MinAgeCount)
+	check(AttributeGender, e.Gender != "", e.GenderProbability >= cfg.MinGenderProbability)
+	check(AttributeNationality, e.Nationality != "", e.NationalityProbability >= cfg.MinNationalityProbability)
+
+	return report
+}
+
+// newEnrichmentReport создает отчет с пустыми, а не nil, списками атрибутов
+func newEnrichmentReport() model.EnrichmentReport {
+	return model.EnrichmentReport{
+		Provided: []string{},
+		Accepted: []string{},
+		Rejected: []string{},
+		Flagged:  []string{},
+		Failed:   []string{},
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/config"
+	"TestEffectiveMobile/cmd/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestAssessConfidence(t *testing.T) {
+	enrichment := func() Enrichment {
+		var e Enrichment
+		e.setAge(AgeResult{Age: 30, Count: 5, Source: model.SourceAgify})
+		e.setGender(GenderResult{Gender: "male", Probability: 0.55, Source: model.SourceGenderize})
+		e.setNationality(NationalityResult{
+			Countries: []model.CountryPrediction{{CountryID: "RU", Probability: 0.9}, {CountryID: "UA", Probability: 0.05}},
+			Source:    model.SourceNationalize,
+		})
+		return e
+	}
+	thresholds := config.EnrichmentConfig{MinAgeCount: 10, MinGenderProbability: 0.8, MinNationalityProbability: 0.5}
+
+	tests := []struct {
+		name       string
+		mode       string
+		wantReport model.EnrichmentReport
+		wantAge    Enrichment
+		wantGender Enrichment
+	}{
+		{
+			name: "недостоверные значения отбрасываются целиком",
+			mode: config.LowConfidenceReject,
+			wantReport: model.EnrichmentReport{
+				Provided: []string{},
+				Accepted: []string{AttributeNationality},
+				Rejected: []string{AttributeAge, AttributeGender},
+				Flagged:  []string{},
+				Failed:   []string{},
+			},
+		},
+		{
+			name: "недостоверные значения остаются с пометкой",
+			mode: config.LowConfidenceFlag,
+			wantReport: model.EnrichmentReport{
+				Provided: []string{},
+				Accepted: []string{AttributeNationality},
+				Rejected: []string{},
+				Flagged:  []string{AttributeAge, AttributeGender},
+				Failed:   []string{},
+			},
+			wantAge:    Enrichment{Age: 30, AgeCount: 5, AgeSource: model.SourceAgify},
+			wantGender: Enrichment{Gender: "male", GenderProbability: 0.55, GenderSource: model.SourceGenderize},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := thresholds
+			cfg.LowConfidenceMode = tt.mode
+			e := enrichment()
+			report := assessConfidence(&cfg, &e, EnrichRequest{Name: "ivan"}, nil)
+			if !reflect.DeepEqual(report, tt.wantReport) {
+				t.Errorf("report = %+v, want %+v", report, tt.wantReport)
+			}
+
+			person := model.Person{Name: "Ivan"}
+			e.apply(&person)
+			if person.Age != tt.wantAge.Age || person.AgeCount != tt.wantAge.AgeCount || person.AgeSource != tt.wantAge.AgeSource {
+				t.Errorf("age = %d, %d, %q, want %d, %d, %q", person.Age, person.AgeCount, person.AgeSource,
+					tt.wantAge.Age, tt.wantAge.AgeCount, tt.wantAge.AgeSource)
+			}
+			if person.Gender != tt.wantGender.Gender || person.GenderProbability != tt.wantGender.GenderProbability ||
+				person.GenderSource != tt.wantGender.GenderSource {
+				t.Errorf("gender = %q, %v, %q, want %q, %v, %q", person.Gender, person.GenderProbability, person.GenderSource,
+					tt.wantGender.Gender, tt.wantGender.GenderProbability, tt.wantGender.GenderSource)
+			}
+			if person.Nationality != "RU" || person.NationalitySource != model.SourceNationalize || len(person.Nationalities) != 2 {
+				t.Errorf("nationality = %q, %q, %v", person.Nationality, person.NationalitySource, person.Nationalities)
+			}
+		})
+	}
+}
+
+func TestAssessConfidenceRejectsNationality(t *testing.T) {
+	var e Enrichment
+	e.setNationality(NationalityResult{
+		Countries: []model.CountryPrediction{{CountryID: "RU", Probability: 0.2}, {CountryID: "UA", Probability: 0.1}},
+		Source:    model.SourceNationalize,
+	})
+	cfg := config.EnrichmentConfig{MinNationalityProbability: 0.5, LowConfidenceMode: config.LowConfidenceReject}
+	report := assessConfidence(&cfg, &e, EnrichRequest{Name: "ivan", Attributes: []string{AttributeNationality}}, nil)
+	if !reflect.DeepEqual(report.Rejected, []string{AttributeNationality}) {
+		t.Fatalf("rejected = %v, want [%s]", report.Rejected, AttributeNationality)
+	}
+	if e.Nationality != "" || e.NationalityProbability != 0 || e.Nationalities != nil || e.NationalitySource != "" {
+		t.Errorf("отброшенная национальность осталась: %+v", e)
+	}
+}
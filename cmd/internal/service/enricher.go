@@ -0,0 +1,382 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Атрибуты, которыми обогащается человек
+const (
+	AttributeAge         = "age"
+	AttributeGender      = "gender"
+	AttributeNationality = "nationality"
+)
+
+var allAttributes = []string{AttributeAge, AttributeGender, AttributeNationality}
+
+// AgeResult наиболее вероятный возраст и размер выборки, на которой он основан
+type AgeResult struct {
+	Age    int
+	Count  int
+	Source string // Провайдер, давший ответ
+}
+
+// GenderResult наиболее вероятный пол и уверенность в нем
+type GenderResult struct {
+	Gender      string
+	Probability float64
+	Count       int
+	Source      string
+}
+
+// NationalityResult распределение вероятных национальностей
+type NationalityResult struct {
+	Countries []model.CountryPrediction
+	Source    string
+}
+
+// AgeProvider определяет наиболее вероятный возраст по имени.
+// Непустой countryID уточняет страну, для которой ищется ответ.
+type AgeProvider interface {
+	Age(ctx context.Context, name, countryID string) (AgeResult, error)
+}
+
+// GenderProvider определяет наиболее вероятный пол по имени.
+// Непустой countryID уточняет страну, для которой ищется ответ.
+type GenderProvider interface {
+	Gender(ctx context.Context, name, countryID string) (GenderResult, error)
+}
+
+// NationalityProvider определяет вероятные национальности по имени
+type NationalityProvider interface {
+	Nationality(ctx context.Context, name string) (NationalityResult, error)
+}
+
+// EnrichRequest описывает, для какого имени и какие атрибуты нужно получить
+type EnrichRequest struct {
+	Name       string
+	CountryID  string   // Страна для уточнения возраста и пола, может быть пустой
+	Attributes []string // nil означает все атрибуты, пустой список — ни одного
+}
+
+// wants сообщает, запрошен ли атрибут
+func (r EnrichRequest) wants(attr string) bool {
+	return r.Attributes == nil || slices.Contains(r.Attributes, attr)
+}
+
+// Enrichment содержит данные, которыми обогащается человек.
+// Атрибут считается полученным, если заполнен его источник.
+type Enrichment struct {
+	Age                    int                       `json:"age"`
+	AgeCount               int                       `json:"age_count"`
+	AgeSource              string                    `json:"age_source"`
+	Gender                 string                    `json:"gender"`
+	GenderProbability      float64                   `json:"gender_probability"`
+	GenderSource           string                    `json:"gender_source"`
+	Nationality            string                    `json:"nationality"`
+	NationalityProbability float64                   `json:"nationality_probability"`
+	Nationalities          []model.CountryPrediction `json:"nationalities"`
+	NationalitySource      string                    `json:"nationality_source"`
+}
+
+func (e *Enrichment) setAge(r AgeResult) {
+	e.Age, e.AgeCount, e.AgeSource = r.Age, r.Count, r.Source
+}
+
+func (e *Enrichment) setGender(r GenderResult) {
+	e.Gender, e.GenderProbability, e.GenderSource = r.Gender, r.Probability, r.Source
+}
+
+// setNationality сохраняет распределение по убыванию вероятности, наиболее вероятная страна становится национальностью
+func (e *Enrichment) setNationality(r NationalityResult) {
+	countries := append([]model.CountryPrediction(nil), r.Countries...)
+	sort.SliceStable(countries, func(i, j int) bool {
+		return countries[i].Probability > countries[j].Probability
+	})
+	e.Nationalities, e.NationalitySource = countries, r.Source
+	e.Nationality, e.NationalityProbability = "", 0
+	if len(countries) > 0 {
+		e.Nationality, e.NationalityProbability = countries[0].CountryID, countries[0].Probability
+	}
+}
+
+// clear удаляет атрибут целиком: значение, достоверность и источник
+func (e *Enrichment) clear(attr string) {
+	switch attr {
+	case AttributeAge:
+		e.Age, e.AgeCount, e.AgeSource = 0, 0, ""
+	case AttributeGender:
+		e.Gender, e.GenderProbability, e.GenderSource = "", 0, ""
+	case AttributeNationality:
+		e.Nationality, e.NationalityProbability, e.NationalitySource = "", 0, ""
+		e.Nationalities = nil
+	}
+}
+
+// has сообщает, получен ли атрибут
+func (e Enrichment) has(attr string) bool {
+	switch attr {
+	case AttributeAge:
+		return e.AgeSource != ""
+	case AttributeGender:
+		return e.GenderSource != ""
+	case AttributeNationality:
+		return e.NationalitySource != ""
+	}
+	return false
+}
+
+// known сообщает, получено ли непустое значение атрибута
+func (e Enrichment) known(attr string) bool {
+	switch attr {
+	case AttributeAge:
+		return e.Age > 0
+	case AttributeGender:
+		return e.Gender != ""
+	case AttributeNationality:
+		return e.Nationality != ""
+	}
+	return false
+}
+
+// covers сообщает, получены ли все запрошенные атрибуты
+func (e Enrichment) covers(req EnrichRequest) bool {
+	return len(e.missing(req)) == 0
+}
+
+// missing возвращает запрошенные атрибуты, которых еще нет
+func (e Enrichment) missing(req EnrichRequest) []string {
+	attrs := []string{}
+	for _, attr := range allAttributes {
+		if req.wants(attr) && !e.has(attr) {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// only оставляет только запрошенные атрибуты
+func (e Enrichment) only(req EnrichRequest) Enrichment {
+	var result Enrichment
+	if req.wants(AttributeAge) {
+		result.Age, result.AgeCount, result.AgeSource = e.Age, e.AgeCount, e.AgeSource
+	}
+	if req.wants(AttributeGender) {
+		result.Gender, result.GenderProbability, result.GenderSource = e.Gender, e.GenderProbability, e.GenderSource
+	}
+	if req.wants(AttributeNationality) {
+		result.Nationality, result.NationalityProbability = e.Nationality, e.NationalityProbability
+		result.Nationalities, result.NationalitySource = e.Nationalities, e.NationalitySource
+	}
+	return result
+}
+
+// merge дополняет данные атрибутами, полученными в other
+func (e *Enrichment) merge(other Enrichment) {
+	if other.has(AttributeAge) {
+		e.Age, e.AgeCount, e.AgeSource = other.Age, other.AgeCount, other.AgeSource
+	}
+	if other.has(AttributeGender) {
+		e.Gender, e.GenderProbability, e.GenderSource = other.Gender, other.GenderProbability, other.GenderSource
+	}
+	if other.has(AttributeNationality) {
+		e.Nationality, e.NationalityProbability = other.Nationality, other.NationalityProbability
+		e.Nationalities, e.NationalitySource = other.Nationalities, other.NationalitySource
+	}
+}
+
+// apply переносит полученные атрибуты в человека, не затрагивая остальные
+func (e Enrichment) apply(person *model.Person) {
+	if e.has(AttributeAge) {
+		person.Age, person.AgeCount, person.AgeSource = e.Age, e.AgeCount, e.AgeSource
+	}
+	if e.has(AttributeGender) {
+		person.Gender, person.GenderProbability, person.GenderSource = e.Gender, e.GenderProbability, e.GenderSource
+	}
+	if e.has(AttributeNationality) {
+		person.Nationality, person.NationalityProbability = e.Nationality, e.NationalityProbability
+		person.Nationalities, person.NationalitySource = e.Nationalities, e.NationalitySource
+	}
+}
+
+// Enricher обогащает данные человека по имени
+type Enricher interface {
+	Enrich(ctx context.Context, req EnrichRequest) (Enrichment, error)
+}
+
+// CompositeEnricher опрашивает провайдеров возраста, пола и национальности
+type CompositeEnricher struct {
+	age         AgeProvider
+	gender      GenderProvider
+	nationality NationalityProvider
+}
+
+// NewCompositeEnricher создает обогатитель из отдельных провайдеров
+func NewCompositeEnricher(age AgeProvider, gender GenderProvider, nationality NationalityProvider) *CompositeEnricher {
+	return &CompositeEnricher{age: age, gender: gender, nationality: nationality}
+}
+
+// Enrich параллельно запрашивает провайдеров запрошенных атрибутов.
+// Если часть провайдеров завершилась ошибкой, возвращает полученные данные и *EnrichmentError.
+func (e *CompositeEnricher) Enrich(ctx context.Context, req EnrichRequest) (Enrichment, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result Enrichment
+		failed = make(map[string]error)
+	)
+
+	run := func(attr string, lookup func() error) {
+		if !req.wants(attr) {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := lookup()
+			if err != nil {
+				mu.Lock()
+				failed[attr] = err
+				mu.Unlock()
+			}
+		}()
+	}
+
+	run(AttributeAge, func() error {
+		age, err := e.age.Age(ctx, req.Name, req.CountryID)
+		if err == nil {
+			result.setAge(age)
+		}
+		return err
+	})
+	run(AttributeGender, func() error {
+		gender, err := e.gender.Gender(ctx, req.Name, req.CountryID)
+		if err == nil {
+			result.setGender(gender)
+		}
+		return err
+	})
+	run(AttributeNationality, func() error {
+		nationality, err := e.nationality.Nationality(ctx, req.Name)
+		if err == nil {
+			result.setNationality(nationality)
+		}
+		return err
+	})
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return result, &EnrichmentError{Name: req.Name, Failed: failed}
+	}
+	return result, nil
+}
+
+// TwoPassEnricher уточняет возраст и пол по стране: если страна не указана в запросе,
+// сначала определяет национальность, а затем запрашивает возраст и пол для наиболее вероятной страны
+type TwoPassEnricher struct {
+	inner Enricher
+}
+
+// NewTwoPassEnricher создает двухпроходный обогатитель
+func NewTwoPassEnricher(inner Enricher) *TwoPassEnricher {
+	return &TwoPassEnricher{inner: inner}
+}
+
+// Enrich реализует интерфейс Enricher
+func (e *TwoPassEnricher) Enrich(ctx context.Context, req EnrichRequest) (Enrichment, error) {
+	if !needsTwoPass(req) {
+		return e.inner.Enrich(ctx, req)
+	}
+
+	// Первый проход: национальность
+	result, nationErr := e.inner.Enrich(ctx, nationalityRequest(req))
+
+	// Второй проход: возраст и пол для наиболее вероятной страны, а при ее отсутствии без уточнения
+	localized, err := e.inner.Enrich(ctx, localizedRequest(req, result.Nationality))
+	result.merge(localized)
+
+	return result, joinEnrichmentErrors(req.Name, nationErr, err)
+}
+
+// needsTwoPass сообщает, нужно ли сначала определить страну: она не указана,
+// а запрошены и национальность, и возраст или пол
+func needsTwoPass(req EnrichRequest) bool {
+	localized := req.wants(AttributeAge) || req.wants(AttributeGender)
+	return req.CountryID == "" && localized && req.wants(AttributeNationality)
+}
+
+// nationalityRequest запрос первого прохода: только национальность
+func nationalityRequest(req EnrichRequest) EnrichRequest {
+	return EnrichRequest{Name: req.Name, Attributes: []string{AttributeNationality}}
+}
+
+// localizedRequest запрос второго прохода: возраст и пол для страны countryID
+func localizedRequest(req EnrichRequest, countryID string) EnrichRequest {
+	localized := EnrichRequest{Name: req.Name, CountryID: countryID, Attributes: []string{}}
+	for _, attr := range []string{AttributeAge, AttributeGender} {
+		if req.wants(attr) {
+			localized.Attributes = append(localized.Attributes, attr)
+		}
+	}
+	return localized
+}
+
+// joinEnrichmentErrors объединяет ошибки нескольких вызовов обогащения в одну *EnrichmentError
+func joinEnrichmentErrors(name string, errs ...error) error {
+	failed := make(map[string]error)
+	for _, err := range errs {
+		var enrichErr *EnrichmentError
+		switch {
+		case err == nil:
+		case errors.As(err, &enrichErr):
+			maps.Copy(failed, enrichErr.Failed)
+		default:
+			return err
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &EnrichmentError{Name: name, Failed: failed}
+}
+
+// EnrichmentError описывает частичный отказ обогащения: какие атрибуты не удалось получить и почему
+type EnrichmentError struct {
+	Name   string
+	Failed map[string]error
+}
+
+// Attributes возвращает отсортированный список атрибутов, которые не удалось получить
+func (e *EnrichmentError) Attributes() []string {
+	attrs := make([]string, 0, len(e.Failed))
+	for attr := range e.Failed {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	return attrs
+}
+
+// Error реализует интерфейс error
+func (e *EnrichmentError) Error() string {
+	parts := make([]string, 0, len(e.Failed))
+	for _, attr := range e.Attributes() {
+		parts = append(parts, fmt.Sprintf("%s: %v", attr, e.Failed[attr]))
+	}
+	return fmt.Sprintf("не удалось обогатить данные для имени %q (%s)", e.Name, strings.Join(parts, "; "))
+}
+
+// Unwrap позволяет проверять исходные ошибки через errors.Is и errors.As
+func (e *EnrichmentError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, attr := range e.Attributes() {
+		errs = append(errs, e.Failed[attr])
+	}
+	return errs
+}
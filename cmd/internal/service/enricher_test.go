@@ -0,0 +1,132 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/fakeapi"
+	"TestEffectiveMobile/cmd/internal/model"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newFakeAPI запускает подменный сервер внешних API на время теста
+func newFakeAPI(t *testing.T, opts fakeapi.Options) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(fakeapi.NewServer(opts))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestEnricher создает обогатитель, обращающийся к серверам agify, genderize и nationalize без повторов
+func newTestEnricher(agify, genderize, nationalize *httptest.Server) *CompositeEnricher {
+	api := func(name string, server *httptest.Server) *APIClient {
+		breaker := NewCircuitBreaker(name, 5, time.Minute)
+		return NewAPIClient(name, server.URL+"/"+name+"/", "", server.Client(), RetryPolicy{MaxAttempts: 1}, breaker, nil)
+	}
+	return NewCompositeEnricher(
+		NewAgifyProvider(api("agify", agify)),
+		NewGenderizeProvider(api("genderize", genderize)),
+		NewNationalizeProvider(api("nationalize", nationalize)),
+	)
+}
+
+func TestCompositeEnricher(t *testing.T) {
+	healthy := fakeapi.Options{Seed: 1}
+	tests := []struct {
+		name                          string
+		agify, genderize, nationalize fakeapi.Options
+		timeout                       time.Duration
+		attributes                    []string
+		wantKnown                     []string
+		wantFailed                    []string
+		wantDeadline                  bool
+	}{
+		{
+			name:  "все источники отвечают",
+			agify: healthy, genderize: healthy, nationalize: healthy,
+			wantKnown: []string{AttributeAge, AttributeGender, AttributeNationality},
+		},
+		{
+			name:  "один источник возвращает 500",
+			agify: healthy, genderize: healthy, nationalize: fakeapi.Options{Seed: 1, ErrorRate: 1},
+			wantKnown:  []string{AttributeAge, AttributeGender},
+			wantFailed: []string{AttributeNationality},
+		},
+		{
+			name:  "один источник не успевает к дедлайну",
+			agify: healthy, genderize: fakeapi.Options{Seed: 1, Latency: time.Second}, nationalize: healthy,
+			timeout:      200 * time.Millisecond,
+			wantKnown:    []string{AttributeAge, AttributeNationality},
+			wantFailed:   []string{AttributeGender},
+			wantDeadline: true,
+		},
+		{
+			name:  "запрашиваются только нужные атрибуты",
+			agify: healthy, genderize: fakeapi.Options{Seed: 1, ErrorRate: 1}, nationalize: healthy,
+			attributes: []string{AttributeAge},
+			wantKnown:  []string{AttributeAge},
+		},
+		{
+			name:  "пустой список атрибутов ничего не запрашивает",
+			agify: fakeapi.Options{ErrorRate: 1}, genderize: fakeapi.Options{ErrorRate: 1}, nationalize: fakeapi.Options{ErrorRate: 1},
+			attributes: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enricher := newTestEnricher(newFakeAPI(t, tt.agify), newFakeAPI(t, tt.genderize), newFakeAPI(t, tt.nationalize))
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			got, err := enricher.Enrich(ctx, EnrichRequest{Name: "ivan", Attributes: tt.attributes})
+			if tt.timeout > 0 && time.Since(start) > 2*tt.timeout {
+				t.Errorf("Enrich() занял %s при дедлайне %s", time.Since(start), tt.timeout)
+			}
+
+			for _, attr := range allAttributes {
+				if known := got.known(attr); known != slices.Contains(tt.wantKnown, attr) {
+					t.Errorf("known(%s) = %v, want %v", attr, known, !known)
+				}
+			}
+			var enrichErr *EnrichmentError
+			switch {
+			case tt.wantFailed == nil && err != nil:
+				t.Fatalf("Enrich() error = %v, want nil", err)
+			case tt.wantFailed != nil && !errors.As(err, &enrichErr):
+				t.Fatalf("Enrich() error = %v, want *EnrichmentError", err)
+			case tt.wantFailed != nil && !slices.Equal(enrichErr.Attributes(), tt.wantFailed):
+				t.Errorf("failed = %v, want %v", enrichErr.Attributes(), tt.wantFailed)
+			}
+			if deadline := errors.Is(err, context.DeadlineExceeded); deadline != tt.wantDeadline {
+				t.Errorf("errors.Is(err, DeadlineExceeded) = %v, want %v", deadline, tt.wantDeadline)
+			}
+		})
+	}
+}
+
+func TestCompositeEnricherSources(t *testing.T) {
+	server := newFakeAPI(t, fakeapi.Options{Seed: 7})
+	got, err := newTestEnricher(server, server, server).Enrich(context.Background(), EnrichRequest{Name: "maria", CountryID: "RU"})
+	if err != nil {
+		t.Fatalf("Enrich() error = %v", err)
+	}
+	if got.AgeSource != model.SourceAgify || got.GenderSource != model.SourceGenderize || got.NationalitySource != model.SourceNationalize {
+		t.Errorf("sources = %s, %s, %s", got.AgeSource, got.GenderSource, got.NationalitySource)
+	}
+
+	// Ответы подменного сервера зависят только от seed и имени
+	again, err := newTestEnricher(server, server, server).Enrich(context.Background(), EnrichRequest{Name: "Maria", CountryID: "RU"})
+	if err != nil {
+		t.Fatalf("Enrich() error = %v", err)
+	}
+	if got.Age != again.Age || got.Gender != again.Gender || got.Nationality != again.Nationality {
+		t.Errorf("ответы различаются: %+v и %+v", got, again)
+	}
+}
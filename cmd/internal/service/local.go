@@ -0,0 +1,165 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//go:embed data/names.csv
+var embeddedDataset string
+
+// localEntry статистика по одному имени из локального справочника
+type localEntry struct {
+	age         AgeResult
+	gender      GenderResult
+	nationality NationalityResult
+}
+
+// LocalProvider отвечает на запросы возраста, пола и национальности по локальному справочнику имен
+// без обращения к сети. Для неизвестного имени возвращается пустой результат, как у внешних API.
+type LocalProvider struct {
+	entries map[string]localEntry // Ключ: имя в нижнем регистре и необязательная страна через @
+}
+
+// NewLocalProvider загружает справочник из CSV-файла path, а при пустом path использует встроенный
+func NewLocalProvider(path string) (*LocalProvider, error) {
+	if path == "" {
+		return LoadLocalProvider(strings.NewReader(embeddedDataset))
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadLocalProvider(file)
+}
+
+// LoadLocalProvider читает справочник в формате CSV с заголовком
+// name,country_id,age,age_count,gender,gender_probability,nationalities,
+// где nationalities перечисляет страны с вероятностями: RU:0.6;UA:0.2
+func LoadLocalProvider(r io.Reader) (*LocalProvider, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 7
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("чтение заголовка справочника имен: %w", err)
+	}
+
+	p := &LocalProvider{entries: make(map[string]localEntry)}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("чтение справочника имен: %w", err)
+		}
+		entry, err := parseLocalEntry(record)
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("справочник имен, строка %d: %w", line, err)
+		}
+		p.entries[localKey(record[0], record[1])] = entry
+	}
+	return p, nil
+}
+
+// parseLocalEntry разбирает строку справочника; пустые поля означают отсутствие данных
+func parseLocalEntry(record []string) (localEntry, error) {
+	entry := localEntry{
+		age:         AgeResult{Source: model.SourceLocal},
+		gender:      GenderResult{Gender: record[4], Source: model.SourceLocal},
+		nationality: NationalityResult{Source: model.SourceLocal},
+	}
+
+	var err error
+	if record[2] != "" {
+		if entry.age.Age, err = strconv.Atoi(record[2]); err != nil {
+			return localEntry{}, fmt.Errorf("некорректный возраст: %w", err)
+		}
+	}
+	if record[3] != "" {
+		if entry.age.Count, err = strconv.Atoi(record[3]); err != nil {
+			return localEntry{}, fmt.Errorf("некорректный размер выборки: %w", err)
+		}
+	}
+	if record[5] != "" {
+		if entry.gender.Probability, err = strconv.ParseFloat(record[5], 64); err != nil {
+			return localEntry{}, fmt.Errorf("некорректная вероятность пола: %w", err)
+		}
+	}
+	for _, item := range strings.Split(record[6], ";") {
+		if item == "" {
+			continue
+		}
+		countryID, probability, ok := strings.Cut(item, ":")
+		if !ok {
+			return localEntry{}, fmt.Errorf("некорректная национальность: %s", item)
+		}
+		prediction := model.CountryPrediction{CountryID: strings.ToUpper(countryID)}
+		if prediction.Probability, err = strconv.ParseFloat(probability, 64); err != nil {
+			return localEntry{}, fmt.Errorf("некорректная вероятность национальности: %w", err)
+		}
+		entry.nationality.Countries = append(entry.nationality.Countries, prediction)
+	}
+	return entry, nil
+}
+
+// localKey приводит имя и страну к ключу справочника
+func localKey(name, countryID string) string {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if countryID != "" {
+		key += "@" + strings.ToLower(countryID)
+	}
+	return key
+}
+
+// lookup ищет запись для страны, а если ее нет, общую запись для имени
+func (p *LocalProvider) lookup(name, countryID string) (localEntry, bool) {
+	if countryID != "" {
+		if entry, ok := p.entries[localKey(name, countryID)]; ok {
+			return entry, true
+		}
+	}
+	entry, ok := p.entries[localKey(name, "")]
+	return entry, ok
+}
+
+// Age реализует интерфейс AgeProvider
+func (p *LocalProvider) Age(_ context.Context, name, countryID string) (AgeResult, error) {
+	entry, ok := p.lookup(name, countryID)
+	if !ok {
+		return AgeResult{Source: model.SourceLocal}, nil
+	}
+	return entry.age, nil
+}
+
+// Gender реализует интерфейс GenderProvider
+func (p *LocalProvider) Gender(_ context.Context, name, countryID string) (GenderResult, error) {
+	entry, ok := p.lookup(name, countryID)
+	if !ok {
+		return GenderResult{Source: model.SourceLocal}, nil
+	}
+	return entry.gender, nil
+}
+
+// Nationality реализует интерфейс NationalityProvider
+func (p *LocalProvider) Nationality(_ context.Context, name string) (NationalityResult, error) {
+	entry, ok := p.lookup(name, "")
+	if !ok {
+		return NationalityResult{Source: model.SourceLocal}, nil
+	}
+	return entry.nationality, nil
+}
+
+// NewLocalEnricher создает обогатитель на основе локального справочника
+func NewLocalEnricher(p *LocalProvider) *CompositeEnricher {
+	return NewCompositeEnricher(p, p, p)
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+)
+
+// icaoTranslit транслитерация кириллицы в латиницу по ICAO Doc 9303 (ГОСТ Р 52535.1-2006),
+// как в загранпаспортах. Таблица для строчных букв: имя приводится к нижнему регистру заранее.
+var icaoTranslit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "ie", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu",
+	'я': "ia",
+	// Украинские и белорусские буквы
+	'є': "ie", 'і': "i", 'ї': "i", 'ґ': "g", 'ў': "u",
+}
+
+var nameFolder = cases.Fold()
+
+// NormalizeName приводит имя к виду, по которому оно ищется во внешних API, кеше и БД:
+// убирает лишние пробелы, приводит к NFC и нижнему регистру и транслитерирует кириллицу.
+// Например, " Иван  ПЕТРОВ" и "ivan petrov" дают одно и то же.
+func NormalizeName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	name = norm.NFC.String(nameFolder.String(norm.NFC.String(name)))
+	return transliterate(name)
+}
+
+// transliterate заменяет кириллические буквы латинскими, остальные символы оставляет как есть
+func transliterate(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	for _, r := range name {
+		if latin, ok := icaoTranslit[r]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,28 @@
+package service
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{" Иван  ПЕТРОВ", "ivan petrov"},
+		{"ivan petrov", "ivan petrov"},
+		{"Щукин", "shchukin"},
+		{"Юлия Ёлкина", "iuliia elkina"},
+		{"Подъячий", "podieiachii"},
+		{"Олесь Гнатюк-Їжак", "oles gnatiuk-izhak"},
+		{"Straße", "strasse"},
+		{"JOSE\u0301", "jos\u00e9"},
+		// Й в разложенном виде (И + бреве) приводится к NFC до транслитерации
+		{"\u0418\u0306оган", "iogan"},
+		{"\tМария\n", "mariia"},
+		{"", ""},
+		{"   ", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeName(tt.in); got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
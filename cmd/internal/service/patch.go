@@ -0,0 +1,303 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/config"
+	"TestEffectiveMobile/cmd/internal/model"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// personFields поля человека, которые можно изменить частичным обновлением
+type personFields struct {
+	Name        string `json:"name"`
+	Surname     string `json:"surname"`
+	Patronymic  string `json:"patronymic"`
+	Age         int    `json:"age"`
+	Gender      string `json:"gender"`
+	Nationality string `json:"nationality"`
+}
+
+// patchableFields имена полей personFields в JSON
+var patchableFields = []string{"name", "surname", "patronymic", "age", "gender", "nationality"}
+
+// PersonPatch частичное обновление человека, применяемое к JSON-документу с полями personFields
+type PersonPatch interface {
+	apply(doc map[string]any) (map[string]any, error)
+}
+
+// mergePatch JSON Merge Patch (RFC 7396)
+type mergePatch map[string]any
+
+// NewMergePatch разбирает JSON Merge Patch (RFC 7396): переданные поля заменяются, null удаляет значение
+func NewMergePatch(data []byte) (PersonPatch, error) {
+	var patch mergePatch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, err
+	}
+	if patch == nil {
+		return nil, fmt.Errorf("merge patch должен быть JSON-объектом")
+	}
+	return patch, nil
+}
+
+func (p mergePatch) apply(doc map[string]any) (map[string]any, error) {
+	return mergeValue(doc, map[string]any(p)).(map[string]any), nil
+}
+
+// mergeValue применяет merge patch к значению target по алгоритму из RFC 7396
+func mergeValue(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergeValue(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// PatchOperation операция JSON Patch (RFC 6902)
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// jsonPatch JSON Patch (RFC 6902)
+type jsonPatch []PatchOperation
+
+// NewJSONPatch разбирает JSON Patch (RFC 6902). Поля человека не вложенные,
+// поэтому поддерживаются только пути верхнего уровня вида /surname.
+func NewJSONPatch(data []byte) (PersonPatch, error) {
+	var patch jsonPatch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, err
+	}
+	for _, op := range patch {
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("операция %s требует value", op.Op)
+			}
+		case "move", "copy":
+			if op.From == "" {
+				return nil, fmt.Errorf("операция %s требует from", op.Op)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("неизвестная операция: %s", op.Op)
+		}
+	}
+	return patch, nil
+}
+
+// apply выполняет операции по порядку; при ошибке любой операции патч не применяется целиком
+func (p jsonPatch) apply(doc map[string]any) (map[string]any, error) {
+	for _, op := range p {
+		key, err := patchKey(op.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		var value any
+		switch op.Op {
+		case "add", "replace", "test":
+			if err = json.Unmarshal(op.Value, &value); err != nil {
+				return nil, fmt.Errorf("%w: некорректное значение для %s", ErrValidation, op.Path)
+			}
+		case "move", "copy":
+			from, err := patchKey(op.From)
+			if err != nil {
+				return nil, err
+			}
+			var ok bool
+			if value, ok = doc[from]; !ok {
+				return nil, fmt.Errorf("%w: поле %s отсутствует", ErrValidation, op.From)
+			}
+			if op.Op == "move" {
+				delete(doc, from)
+			}
+		}
+
+		_, exists := doc[key]
+		switch op.Op {
+		case "add", "move", "copy":
+			doc[key] = value
+		case "replace":
+			if !exists {
+				return nil, fmt.Errorf("%w: поле %s отсутствует", ErrValidation, op.Path)
+			}
+			doc[key] = value
+		case "remove":
+			if !exists {
+				return nil, fmt.Errorf("%w: поле %s отсутствует", ErrValidation, op.Path)
+			}
+			delete(doc, key)
+		case "test":
+			if !reflect.DeepEqual(doc[key], value) {
+				return nil, fmt.Errorf("%w: значение %s не совпадает с ожидаемым", ErrConflict, op.Path)
+			}
+		}
+	}
+	return doc, nil
+}
+
+// patchKey возвращает имя поля из JSON Pointer (RFC 6901) верхнего уровня
+func patchKey(pointer string) (string, error) {
+	if !strings.HasPrefix(pointer, "/") || strings.Count(pointer, "/") != 1 {
+		return "", fmt.Errorf("%w: поддерживаются только пути верхнего уровня, получен %q", ErrValidation, pointer)
+	}
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(pointer[1:]), nil
+}
+
+// applyPatch применяет patch к изменяемым полям человека
+func applyPatch(person model.Person, patch PersonPatch) (personFields, error) {
+	fields := personFields{
+		Name: person.Name, Surname: person.Surname, Patronymic: person.Patronymic,
+		Age: person.Age, Gender: person.Gender, Nationality: person.Nationality,
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fields, err
+	}
+	var doc map[string]any
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return fields, err
+	}
+
+	if doc, err = patch.apply(doc); err != nil {
+		return fields, err
+	}
+	for key := range doc {
+		if !slices.Contains(patchableFields, key) {
+			return fields, fmt.Errorf("%w: поле %s нельзя изменить", ErrValidation, key)
+		}
+	}
+
+	// Удаленные поля получают нулевые значения
+	if data, err = json.Marshal(doc); err != nil {
+		return fields, err
+	}
+	var patched personFields
+	if err = json.NewDecoder(bytes.NewReader(data)).Decode(&patched); err != nil {
+		return fields, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return patched, nil
+}
+
+// PatchPerson частично обновляет человека. Измененные возраст, пол и национальность считаются
+// исправленными оператором, остальные данные обогащения сохраняются. Если изменилось имя и reenrich
+// установлен, значения, полученные из внешних источников, запрашиваются заново по правилам AddPerson.
+// Если version не 0 и не совпадает с текущей версией человека, возвращается ErrPreconditionFailed;
+// патч применяется к прочитанной версии и не затирает изменения, сохраненные после чтения.
+func (s *PersonServiceImpl) PatchPerson(ctx context.Context, id, version int, patch PersonPatch, reenrich bool) (*model.Person, error) {
+	current, err := s.repo.GetPerson(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != current.Version {
+		return nil, ErrPreconditionFailed
+	}
+	fields, err := applyPatch(*current, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	person := *current
+	person.Name, person.Surname, person.Patronymic = fields.Name, fields.Surname, fields.Patronymic
+	if fields.Age != current.Age {
+		setAttribute(&person, AttributeAge, Enrichment{Age: fields.Age})
+	}
+	if fields.Gender != current.Gender {
+		setAttribute(&person, AttributeGender, Enrichment{Gender: fields.Gender})
+	}
+	if fields.Nationality != current.Nationality {
+		setAttribute(&person, AttributeNationality, Enrichment{Nationality: fields.Nationality})
+	}
+	if err = validatePerson(person); err != nil {
+		return nil, err
+	}
+	person.NormalizedName = NormalizeName(person.Name)
+
+	save := func(enriched bool) (*model.Person, error) {
+		if person.Version, err = s.repo.PatchPerson(person, enriched); err != nil {
+			return nil, err
+		}
+		return &person, nil
+	}
+
+	req := requestFor(person)
+	if !reenrich || person.Name == current.Name || len(req.Attributes) == 0 {
+		return save(false)
+	}
+
+	// Значения, полученные для прежнего имени, больше не относятся к человеку
+	slog.Info("Повторное обогащение после смены имени", "id", id, "name", person.Name, "policy", s.cfg.Policy)
+	for _, attr := range req.Attributes {
+		setAttribute(&person, attr, Enrichment{})
+	}
+	req.CountryID = countryFor(s.cfg, person, "")
+
+	if s.cfg.Policy == config.PolicyAsync {
+		person.EnrichmentStatus = model.EnrichmentPending
+		if person.Version, err = s.repo.PatchPersonWithJob(person, req.CountryID); err != nil {
+			return nil, err
+		}
+		return &person, nil
+	}
+
+	enrichment, err := s.enrich(ctx, req)
+	person.EnrichmentStatus = model.EnrichmentComplete
+	if err != nil {
+		if s.cfg.Policy == config.PolicyStrict && !s.degradable(err) {
+			slog.Error("Ошибка обогащения данных", "name", person.Name, "error", err)
+			return nil, err
+		}
+		slog.Warn("Человек сохраняется без части данных обогащения", "name", person.Name, "error", err)
+		person.EnrichmentStatus = model.EnrichmentFailed
+	}
+	report := assessConfidence(s.cfg, &enrichment, req, err)
+	enrichment.apply(&person)
+	person.LowConfidence = report.Flagged
+	return save(true)
+}
+
+// setAttribute заменяет значение атрибута значением из e и снимает пометку низкой достоверности.
+// Непустое значение считается исправленным оператором, пустое делает атрибут неизвестным.
+func setAttribute(person *model.Person, attr string, e Enrichment) {
+	source := ""
+	if e.known(attr) {
+		source = model.SourceManual
+	}
+	switch attr {
+	case AttributeAge:
+		person.Age, person.AgeCount, person.AgeSource = e.Age, 0, source
+	case AttributeGender:
+		person.Gender, person.GenderProbability, person.GenderSource = e.Gender, 0, source
+	case AttributeNationality:
+		person.Nationality, person.NationalityProbability, person.NationalitySource = e.Nationality, 0, source
+		person.Nationalities = nil
+	}
+	var lowConfidence []string
+	for _, flagged := range person.LowConfidence {
+		if flagged != attr {
+			lowConfidence = append(lowConfidence, flagged)
+		}
+	}
+	person.LowConfidence = lowConfidence
+}
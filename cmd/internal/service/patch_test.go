@@ -0,0 +1,149 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"errors"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	person := model.Person{Name: "Ivan", Surname: "Ivanov", Patronymic: "Ivanovich", Age: 30, Gender: "male", Nationality: "RU"}
+	original := personFields{Name: "Ivan", Surname: "Ivanov", Patronymic: "Ivanovich", Age: 30, Gender: "male", Nationality: "RU"}
+	with := func(change func(f *personFields)) personFields {
+		fields := original
+		change(&fields)
+		return fields
+	}
+
+	tests := []struct {
+		name    string
+		merge   string // JSON Merge Patch; если пусто, применяется jsonPatch
+		json    string
+		want    personFields
+		wantErr error
+	}{
+		{
+			name:  "merge patch заменяет поля",
+			merge: `{"surname": "Petrov", "age": 31}`,
+			want:  with(func(f *personFields) { f.Surname, f.Age = "Petrov", 31 }),
+		},
+		{
+			name:  "merge patch: null удаляет поле",
+			merge: `{"patronymic": null, "age": null}`,
+			want:  with(func(f *personFields) { f.Patronymic, f.Age = "", 0 }),
+		},
+		{
+			name:    "merge patch: поле нельзя изменить",
+			merge:   `{"id": 5}`,
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "merge patch: тип не совпадает",
+			merge:   `{"age": "x"}`,
+			wantErr: ErrValidation,
+		},
+		{
+			name: "test и replace",
+			json: `[{"op": "test", "path": "/age", "value": 30}, {"op": "replace", "path": "/age", "value": 31}]`,
+			want: with(func(f *personFields) { f.Age = 31 }),
+		},
+		{
+			name:    "test не совпадает",
+			json:    `[{"op": "test", "path": "/surname", "value": "Petrov"}, {"op": "replace", "path": "/age", "value": 31}]`,
+			wantErr: ErrConflict,
+		},
+		{
+			name: "remove удаляет поле",
+			json: `[{"op": "remove", "path": "/patronymic"}]`,
+			want: with(func(f *personFields) { f.Patronymic = "" }),
+		},
+		{
+			name:    "remove отсутствующего поля",
+			json:    `[{"op": "remove", "path": "/email"}]`,
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "replace отсутствующего поля",
+			json:    `[{"op": "replace", "path": "/email", "value": "ivan@example.com"}]`,
+			wantErr: ErrValidation,
+		},
+		{
+			name: "move переносит значение",
+			json: `[{"op": "move", "from": "/surname", "path": "/patronymic"}]`,
+			want: with(func(f *personFields) { f.Surname, f.Patronymic = "", "Ivanov" }),
+		},
+		{
+			name: "copy копирует значение",
+			json: `[{"op": "copy", "from": "/name", "path": "/surname"}]`,
+			want: with(func(f *personFields) { f.Surname = "Ivan" }),
+		},
+		{
+			name:    "move из отсутствующего поля",
+			json:    `[{"op": "move", "from": "/email", "path": "/surname"}]`,
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "add поля, которое нельзя изменить",
+			json:    `[{"op": "add", "path": "/version", "value": 7}]`,
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "вложенный путь",
+			json:    `[{"op": "replace", "path": "/name/first", "value": "Petr"}]`,
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "путь без слеша",
+			json:    `[{"op": "replace", "path": "name", "value": "Petr"}]`,
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "тип не совпадает",
+			json:    `[{"op": "replace", "path": "/age", "value": "x"}]`,
+			wantErr: ErrValidation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch PersonPatch
+			var err error
+			if tt.merge != "" {
+				patch, err = NewMergePatch([]byte(tt.merge))
+			} else {
+				patch, err = NewJSONPatch([]byte(tt.json))
+			}
+			if err != nil {
+				t.Fatalf("разбор патча: %v", err)
+			}
+
+			got, err := applyPatch(person, patch)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("applyPatch() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("applyPatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "корректный патч", data: `[{"op": "add", "path": "/age", "value": 30}, {"op": "remove", "path": "/gender"}]`},
+		{name: "неизвестная операция", data: `[{"op": "increment", "path": "/age"}]`, wantErr: true},
+		{name: "replace без value", data: `[{"op": "replace", "path": "/age"}]`, wantErr: true},
+		{name: "copy без from", data: `[{"op": "copy", "path": "/surname"}]`, wantErr: true},
+		{name: "не массив", data: `{"op": "remove", "path": "/age"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewJSONPatch([]byte(tt.data)); (err != nil) != tt.wantErr {
+				t.Errorf("NewJSONPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/config"
+	"TestEffectiveMobile/cmd/internal/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AgifyProvider получает возраст из api.agify.io
+type AgifyProvider struct {
+	api *APIClient
+}
+
+// NewAgifyProvider создает провайдера возраста
+func NewAgifyProvider(api *APIClient) *AgifyProvider {
+	return &AgifyProvider{api: api}
+}
+
+// Age реализует интерфейс AgeProvider
+func (p *AgifyProvider) Age(ctx context.Context, name, countryID string) (AgeResult, error) {
+	var data model.AgifyResponse
+	if err := p.api.GetJSON(ctx, nameQuery(name, countryID), &data); err != nil {
+		slog.Error("Ошибка получения возраста", "error", err)
+		return AgeResult{}, err
+	}
+	return AgeResult{Age: data.Age, Count: data.Count, Source: model.SourceAgify}, nil
+}
+
+// AgeBatch реализует интерфейс BatchAgeProvider
+func (p *AgifyProvider) AgeBatch(ctx context.Context, names []string, countryID string) ([]AgeResult, error) {
+	data, err := getBatchJSON[model.AgifyResponse](ctx, p.api, names, countryID)
+	if err != nil {
+		slog.Error("Ошибка пакетного получения возраста", "error", err)
+		return nil, err
+	}
+	results := make([]AgeResult, len(data))
+	for i, d := range data {
+		results[i] = AgeResult{Age: d.Age, Count: d.Count, Source: model.SourceAgify}
+	}
+	return results, nil
+}
+
+// GenderizeProvider получает пол из api.genderize.io
+type GenderizeProvider struct {
+	api *APIClient
+}
+
+// NewGenderizeProvider создает провайдера пола
+func NewGenderizeProvider(api *APIClient) *GenderizeProvider {
+	return &GenderizeProvider{api: api}
+}
+
+// Gender реализует интерфейс GenderProvider
+func (p *GenderizeProvider) Gender(ctx context.Context, name, countryID string) (GenderResult, error) {
+	var data model.GenderizeResponse
+	if err := p.api.GetJSON(ctx, nameQuery(name, countryID), &data); err != nil {
+		slog.Error("Ошибка получения пола", "error", err)
+		return GenderResult{}, err
+	}
+	return GenderResult{Gender: data.Gender, Probability: data.Probability, Count: data.Count, Source: model.SourceGenderize}, nil
+}
+
+// GenderBatch реализует интерфейс BatchGenderProvider
+func (p *GenderizeProvider) GenderBatch(ctx context.Context, names []string, countryID string) ([]GenderResult, error) {
+	data, err := getBatchJSON[model.GenderizeResponse](ctx, p.api, names, countryID)
+	if err != nil {
+		slog.Error("Ошибка пакетного получения пола", "error", err)
+		return nil, err
+	}
+	results := make([]GenderResult, len(data))
+	for i, d := range data {
+		results[i] = GenderResult{Gender: d.Gender, Probability: d.Probability, Count: d.Count, Source: model.SourceGenderize}
+	}
+	return results, nil
+}
+
+// NationalizeProvider получает национальность из api.nationalize.io
+type NationalizeProvider struct {
+	api *APIClient
+}
+
+// NewNationalizeProvider создает провайдера национальности
+func NewNationalizeProvider(api *APIClient) *NationalizeProvider {
+	return &NationalizeProvider{api: api}
+}
+
+// Nationality реализует интерфейс NationalityProvider
+func (p *NationalizeProvider) Nationality(ctx context.Context, name string) (NationalityResult, error) {
+	var data model.NationalizeResponse
+	if err := p.api.GetJSON(ctx, nameQuery(name, ""), &data); err != nil {
+		slog.Error("Ошибка получения национальности", "error", err)
+		return NationalityResult{}, err
+	}
+
+	if len(data.Country) == 0 {
+		slog.Info("Не удалось определить национальность для имени", "name", name)
+	}
+	return NationalityResult{Countries: data.Country, Source: model.SourceNationalize}, nil
+}
+
+// NationalityBatch реализует интерфейс BatchNationalityProvider
+func (p *NationalizeProvider) NationalityBatch(ctx context.Context, names []string) ([]NationalityResult, error) {
+	data, err := getBatchJSON[model.NationalizeResponse](ctx, p.api, names, "")
+	if err != nil {
+		slog.Error("Ошибка пакетного получения национальности", "error", err)
+		return nil, err
+	}
+	results := make([]NationalityResult, len(data))
+	for i, d := range data {
+		results[i] = NationalityResult{Countries: d.Country, Source: model.SourceNationalize}
+	}
+	return results, nil
+}
+
+// NewHTTPEnricher создает обогатитель на основе agify, genderize и nationalize.
+// У каждого провайдера свой автоматический выключатель и учет квоты, который возвращается вторым значением.
+func NewHTTPEnricher(cfg *config.EnrichmentConfig) (*CompositeEnricher, []*QuotaTracker) {
+	client := &http.Client{Timeout: cfg.Timeout}
+	retry := RetryPolicy{MaxAttempts: cfg.RetryMaxAttempts, BaseDelay: cfg.RetryBaseDelay, MaxDelay: cfg.RetryMaxDelay}
+	var quotas []*QuotaTracker
+	newAPI := func(name, baseURL, apiKey string) *APIClient {
+		breaker := NewCircuitBreaker(name, cfg.BreakerFailureThreshold, cfg.BreakerOpenTimeout)
+		quota := NewQuotaTracker(name, cfg.QuotaLowWatermark, cfg.QuotaLowMode, cfg.QuotaThrottleInterval, cfg.QuotaResetWindow)
+		quotas = append(quotas, quota)
+		return NewAPIClient(name, baseURL, apiKey, client, retry, breaker, quota)
+	}
+
+	enricher := NewCompositeEnricher(
+		NewAgifyProvider(newAPI("agify", cfg.AgifyURL, cfg.AgifyAPIKey)),
+		NewGenderizeProvider(newAPI("genderize", cfg.GenderizeURL, cfg.GenderizeAPIKey)),
+		NewNationalizeProvider(newAPI("nationalize", cfg.NationalizeURL, cfg.NationalizeAPIKey)),
+	)
+	return enricher, quotas
+}
+
+// APIClient выполняет запросы к внешнему API обогащения: проверяет статус ответа,
+// повторяет временные ошибки с экспоненциальной задержкой и учитывает Retry-After
+type APIClient struct {
+	name    string
+	baseURL string
+	apiKey  string // Ключ платного тарифа, передается параметром apikey
+	client  *http.Client
+	retry   RetryPolicy
+	breaker *CircuitBreaker
+	quota   *QuotaTracker // Может быть nil, тогда квота не учитывается
+}
+
+// NewAPIClient создает клиента внешнего API; пустой apiKey означает бесплатный тариф
+func NewAPIClient(name, baseURL, apiKey string, client *http.Client, retry RetryPolicy, breaker *CircuitBreaker, quota *QuotaTracker) *APIClient {
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = 1
+	}
+	return &APIClient{name: name, baseURL: baseURL, apiKey: apiKey, client: client, retry: retry, breaker: breaker, quota: quota}
+}
+
+// nameQuery формирует параметры запроса по имени с необязательным уточнением страны
+func nameQuery(name, countryID string) url.Values {
+	query := url.Values{"name": {name}}
+	if countryID != "" {
+		query.Set("country_id", countryID)
+	}
+	return query
+}
+
+// namesQuery формирует параметры пакетного запроса name[] с необязательным уточнением страны
+func namesQuery(names []string, countryID string) url.Values {
+	query := url.Values{"name[]": names}
+	if countryID != "" {
+		query.Set("country_id", countryID)
+	}
+	return query
+}
+
+// getBatchJSON выполняет пакетный запрос по именам names и декодирует массив ответов в порядке имен
+func getBatchJSON[T any](ctx context.Context, api *APIClient, names []string, countryID string) ([]T, error) {
+	if len(names) > maxBatchSize {
+		return nil, fmt.Errorf("%s: в пакетном запросе не более %d имен, передано %d", api.name, maxBatchSize, len(names))
+	}
+	var data []T
+	if err := api.GetJSON(ctx, namesQuery(names, countryID), &data); err != nil {
+		return nil, err
+	}
+	if len(data) != len(names) {
+		return nil, fmt.Errorf("%s: получено %d ответов на %d имен", api.name, len(data), len(names))
+	}
+	return data, nil
+}
+
+// GetJSON выполняет GET-запрос с параметрами query и декодирует ответ в target
+func (c *APIClient) GetJSON(ctx context.Context, query url.Values, target interface{}) error {
+	if c.quota != nil {
+		if err := c.quota.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if err := c.breaker.Allow(); err != nil {
+		return fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	var err error
+	for attempt := 1; attempt <= c.retry.MaxAttempts; attempt++ {
+		err = c.do(ctx, query, target)
+		if err == nil {
+			c.breaker.Success()
+			return nil
+		}
+		if !retryable(ctx, err) || attempt == c.retry.MaxAttempts {
+			break
+		}
+
+		delay := c.retry.backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			break
+		}
+
+		slog.Warn("Повтор запроса к внешнему API", "provider", c.name, "attempt", attempt, "delay", delay, "error", err)
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			break
+		}
+	}
+
+	if providerFault(err) {
+		c.breaker.Failure()
+	} else {
+		c.breaker.Release()
+	}
+	return err
+}
+
+// do выполняет одну попытку запроса
+func (c *APIClient) do(ctx context.Context, query url.Values, target interface{}) error {
+	if c.apiKey != "" {
+		query = maps.Clone(query)
+		query.Set("apikey", c.apiKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Ключ не должен попадать в логи вместе с адресом запроса
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = c.baseURL
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if c.quota != nil {
+		c.quota.Update(resp.Header)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{
+			Provider:   c.name,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("%s: некорректный ответ: %w", c.name, err)
+	}
+	return nil
+}
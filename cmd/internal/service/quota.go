@@ -0,0 +1,147 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Ошибки квоты внешнего API. Запрос при этом не отправляется и не влияет на выключатель провайдера.
+var (
+	ErrQuotaExhausted = errors.New("квота провайдера исчерпана")
+	ErrQuotaLow       = errors.New("квота провайдера на исходе")
+)
+
+// QuotaState остаток квоты провайдера по заголовкам X-Rate-Limit-* последнего ответа
+type QuotaState struct {
+	Provider  string    `json:"provider"`
+	Known     bool      `json:"known"` // Провайдер уже сообщил остаток квоты
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Low       bool      `json:"low"` // Остаток не выше порога
+	Throttled int64     `json:"throttled"`
+	Rejected  int64     `json:"rejected"` // Запросы, не отправленные из-за квоты
+}
+
+// QuotaTracker следит за остатком квоты провайдера и при его снижении
+// замедляет запросы или отказывает в них, чтобы сработал запасной источник
+type QuotaTracker struct {
+	mu           sync.Mutex
+	state        QuotaState
+	lowWatermark int
+	mode         string
+	interval     time.Duration
+	resetWindow  time.Duration
+	next         time.Time        // Время, раньше которого не отправляется следующий запрос при замедлении
+	now          func() time.Time // Часы; подменяются в тестах
+}
+
+// NewQuotaTracker создает учет квоты провайдера. При остатке не выше lowWatermark
+// запросы отправляются не чаще interval (config.QuotaLowThrottle) или не отправляются вовсе (config.QuotaLowFallback).
+// Если провайдер не сообщил время обновления квоты, оно считается равным resetWindow от последнего ответа.
+func NewQuotaTracker(provider string, lowWatermark int, mode string, interval, resetWindow time.Duration) *QuotaTracker {
+	return &QuotaTracker{
+		state:        QuotaState{Provider: provider},
+		lowWatermark: lowWatermark, mode: mode, interval: interval, resetWindow: resetWindow,
+		now: time.Now,
+	}
+}
+
+// Wait вызывается перед запросом и решает, можно ли его отправить
+func (q *QuotaTracker) Wait(ctx context.Context) error {
+	q.mu.Lock()
+	now := q.now()
+	if q.state.Known && now.After(q.resetAt()) {
+		// Квота обновилась, остаток неизвестен до следующего ответа
+		q.state.Known, q.state.Low = false, false
+	}
+	if !q.state.Known || q.state.Remaining > q.lowWatermark {
+		q.next = now.Add(q.interval)
+		q.mu.Unlock()
+		return nil
+	}
+
+	if q.state.Remaining <= 0 {
+		q.state.Rejected++
+		q.mu.Unlock()
+		return fmt.Errorf("%s: %w до %s", q.state.Provider, ErrQuotaExhausted, q.resetAt().Format(time.RFC3339))
+	}
+	if q.mode == config.QuotaLowFallback {
+		q.state.Rejected++
+		q.mu.Unlock()
+		return fmt.Errorf("%s: %w (осталось %d)", q.state.Provider, ErrQuotaLow, q.state.Remaining)
+	}
+
+	// Занимаем следующий свободный интервал, чтобы параллельные запросы тоже шли по одному
+	slot := now
+	if q.next.After(now) {
+		slot = q.next
+	}
+	delay := slot.Sub(now)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		q.state.Rejected++
+		q.mu.Unlock()
+		return fmt.Errorf("%s: %w, ожидание %s превышает дедлайн", q.state.Provider, ErrQuotaLow, delay)
+	}
+	q.next = slot.Add(q.interval)
+	if delay <= 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	q.state.Throttled++
+	q.mu.Unlock()
+
+	slog.Debug("Замедление запроса из-за квоты", "provider", q.state.Provider, "delay", delay)
+	return sleep(ctx, delay)
+}
+
+// resetAt возвращает время обновления квоты. Если провайдер его не сообщил, после resetWindow
+// следующий запрос все равно отправляется: без запроса не будет и ответа с новым остатком.
+func (q *QuotaTracker) resetAt() time.Time {
+	if q.state.ResetAt.IsZero() {
+		return q.state.UpdatedAt.Add(q.resetWindow)
+	}
+	return q.state.ResetAt
+}
+
+// Update запоминает остаток квоты из заголовков ответа; ответы без заголовков не меняют состояние
+func (q *QuotaTracker) Update(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-Rate-Limit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	q.state.Known, q.state.Remaining, q.state.UpdatedAt = true, remaining, now
+	if limit, err := strconv.Atoi(header.Get("X-Rate-Limit-Limit")); err == nil {
+		q.state.Limit = limit
+	}
+	// X-Rate-Limit-Reset содержит число секунд до обновления квоты
+	q.state.ResetAt = time.Time{}
+	if reset, err := strconv.Atoi(header.Get("X-Rate-Limit-Reset")); err == nil {
+		q.state.ResetAt = now.Add(time.Duration(reset) * time.Second)
+	}
+
+	low := remaining <= q.lowWatermark
+	if low && !q.state.Low {
+		slog.Warn("Квота провайдера на исходе", "provider", q.state.Provider, "remaining", remaining, "mode", q.mode)
+	}
+	q.state.Low = low
+}
+
+// State возвращает текущее состояние квоты
+func (q *QuotaTracker) State() QuotaState {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.state
+}
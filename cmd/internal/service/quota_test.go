@@ -0,0 +1,177 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/config"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// quotaHeader собирает заголовки X-Rate-Limit-*; пустые значения не передаются
+func quotaHeader(limit, remaining, reset string) http.Header {
+	header := http.Header{}
+	for key, value := range map[string]string{
+		"X-Rate-Limit-Limit": limit, "X-Rate-Limit-Remaining": remaining, "X-Rate-Limit-Reset": reset,
+	} {
+		if value != "" {
+			header.Set(key, value)
+		}
+	}
+	return header
+}
+
+func TestQuotaTrackerUpdate(t *testing.T) {
+	clock := newFakeClock()
+	quota := NewQuotaTracker("agify", 5, config.QuotaLowFallback, time.Second, time.Hour)
+	quota.now = clock.Now
+
+	quota.Update(quotaHeader("100", "40", "60"))
+	state := quota.State()
+	if !state.Known || state.Limit != 100 || state.Remaining != 40 || state.Low {
+		t.Errorf("State() = %+v, want known, limit 100, remaining 40", state)
+	}
+	if want := clock.Now().Add(time.Minute); !state.ResetAt.Equal(want) || !state.UpdatedAt.Equal(clock.Now()) {
+		t.Errorf("ResetAt = %s, UpdatedAt = %s, want %s, %s", state.ResetAt, state.UpdatedAt, want, clock.Now())
+	}
+
+	// Ответ без заголовков квоты состояние не меняет
+	clock.Advance(time.Second)
+	quota.Update(http.Header{})
+	if got := quota.State(); got != state {
+		t.Errorf("State() после ответа без заголовков = %+v, want %+v", got, state)
+	}
+
+	// Остаток уменьшается с каждым ответом; лимит и время обновления берутся из последнего
+	quota.Update(quotaHeader("", "5", ""))
+	state = quota.State()
+	if state.Limit != 100 || state.Remaining != 5 || !state.Low || !state.ResetAt.IsZero() {
+		t.Errorf("State() = %+v, want limit 100, remaining 5, low, без ResetAt", state)
+	}
+}
+
+func TestQuotaTrackerWait(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		interval      time.Duration
+		header        http.Header // nil — провайдер еще не отвечал
+		advance       time.Duration
+		waits         int
+		timeout       time.Duration
+		wantErr       error
+		wantKnown     bool
+		wantRejected  int64
+		wantThrottled int64
+	}{
+		{
+			name:  "остаток неизвестен",
+			mode:  config.QuotaLowFallback,
+			waits: 3,
+		},
+		{
+			name:   "остаток выше порога",
+			mode:   config.QuotaLowFallback,
+			header: quotaHeader("100", "50", "60"),
+			waits:  3, wantKnown: true,
+		},
+		{
+			name:   "квота исчерпана",
+			mode:   config.QuotaLowThrottle,
+			header: quotaHeader("100", "0", "60"),
+			waits:  2, wantKnown: true,
+			wantErr: ErrQuotaExhausted, wantRejected: 2,
+		},
+		{
+			name:    "квота исчерпана до reset",
+			mode:    config.QuotaLowThrottle,
+			header:  quotaHeader("100", "0", "60"),
+			advance: 59 * time.Second,
+			waits:   1, wantKnown: true,
+			wantErr: ErrQuotaExhausted, wantRejected: 1,
+		},
+		{
+			name:    "после reset квота обновляется",
+			mode:    config.QuotaLowThrottle,
+			header:  quotaHeader("100", "0", "60"),
+			advance: 61 * time.Second,
+			waits:   1,
+		},
+		{
+			name:    "без reset квота обновляется через resetWindow",
+			mode:    config.QuotaLowThrottle,
+			header:  quotaHeader("100", "0", ""),
+			advance: 5*time.Minute + time.Second,
+			waits:   1,
+		},
+		{
+			name:    "без reset квота исчерпана до resetWindow",
+			mode:    config.QuotaLowThrottle,
+			header:  quotaHeader("100", "0", ""),
+			advance: 4 * time.Minute,
+			waits:   1, wantKnown: true,
+			wantErr: ErrQuotaExhausted, wantRejected: 1,
+		},
+		{
+			name:   "fallback на исходе",
+			mode:   config.QuotaLowFallback,
+			header: quotaHeader("100", "5", "60"),
+			waits:  1, wantKnown: true,
+			wantErr: ErrQuotaLow, wantRejected: 1,
+		},
+		{
+			name:     "throttle замедляет запросы",
+			mode:     config.QuotaLowThrottle,
+			interval: 20 * time.Millisecond,
+			header:   quotaHeader("100", "5", "60"),
+			waits:    2, wantKnown: true,
+			wantThrottled: 1,
+		},
+		{
+			name:     "throttle не ждет дольше дедлайна",
+			mode:     config.QuotaLowThrottle,
+			interval: time.Hour,
+			header:   quotaHeader("100", "5", "60"),
+			waits:    2, timeout: time.Second, wantKnown: true,
+			wantErr: ErrQuotaLow, wantRejected: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			quota := NewQuotaTracker("agify", 5, tt.mode, tt.interval, 5*time.Minute)
+			quota.now = clock.Now
+			if tt.header != nil {
+				quota.Update(tt.header)
+			}
+			clock.Advance(tt.advance)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			start := time.Now()
+			var err error
+			for range tt.waits {
+				err = quota.Wait(ctx)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Wait() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantThrottled > 0 && time.Since(start) < tt.interval {
+				t.Errorf("Wait() занял %s, want at least %s", time.Since(start), tt.interval)
+			}
+
+			state := quota.State()
+			if state.Known != tt.wantKnown {
+				t.Errorf("Known = %v, want %v", state.Known, tt.wantKnown)
+			}
+			if state.Rejected != tt.wantRejected || state.Throttled != tt.wantThrottled {
+				t.Errorf("Rejected, Throttled = %d, %d, want %d, %d", state.Rejected, state.Throttled, tt.wantRejected, tt.wantThrottled)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/config"
+	"TestEffectiveMobile/cmd/internal/model"
+	"TestEffectiveMobile/cmd/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+)
+
+// reenrichPageSize число людей, которые читаются из БД и обогащаются одной пачкой
+const reenrichPageSize = 100
+
+// reenrichSources источники, по которым можно отбирать людей для повторного обогащения
+var reenrichSources = []string{
+	model.SourceUser, model.SourceManual, model.SourceAgify, model.SourceGenderize,
+	model.SourceNationalize, model.SourceLocal, model.SourceStatic,
+}
+
+// ReenrichOptions параметры повторного обогащения
+type ReenrichOptions struct {
+	Filter model.ReenrichFilter `json:"filter"`
+	DryRun bool                 `json:"dry_run"` // Только показать, что изменится
+}
+
+// FieldChange изменение одного атрибута; пустое значение означает его отсутствие
+type FieldChange struct {
+	Attribute string `json:"attribute"`
+	Old       string `json:"old"`
+	New       string `json:"new"`
+	Source    string `json:"source"`
+}
+
+// ReenrichResult итог повторного обогащения одного человека
+type ReenrichResult struct {
+	PersonID int           `json:"person_id"`
+	Name     string        `json:"name"`
+	Changes  []FieldChange `json:"changes,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// ReenrichReport итог повторного обогащения. В Results попадают только люди с изменениями или ошибками.
+type ReenrichReport struct {
+	DryRun    bool             `json:"dry_run"`
+	Processed int              `json:"processed"`
+	Changed   int              `json:"changed"`
+	Failed    int              `json:"failed"`
+	Results   []ReenrichResult `json:"results"`
+}
+
+// ValidateReenrichFilter проверяет фильтр повторного обогащения
+func ValidateReenrichFilter(filter model.ReenrichFilter) error {
+	if filter.FromID < 0 || filter.ToID < 0 || (filter.ToID > 0 && filter.ToID < filter.FromID) {
+		return fmt.Errorf("некорректный диапазон id: %d-%d", filter.FromID, filter.ToID)
+	}
+	if filter.Limit < 0 {
+		return fmt.Errorf("некорректное ограничение числа людей: %d", filter.Limit)
+	}
+	for _, attr := range filter.Missing {
+		if !slices.Contains(allAttributes, attr) {
+			return fmt.Errorf("неизвестный атрибут: %s", attr)
+		}
+	}
+	for _, source := range filter.Sources {
+		if !slices.Contains(reenrichSources, source) {
+			return fmt.Errorf("неизвестный источник: %s", source)
+		}
+	}
+	return nil
+}
+
+// Reenricher повторно обогащает сохраненных людей. Значения, заданные клиентом
+// или оператором, не перезаписываются, а существующие значения не стираются пустыми ответами.
+type Reenricher struct {
+	repo     repository.PersonRepository
+	enricher Enricher
+	cfg      *config.EnrichmentConfig
+}
+
+// NewReenricher создает сервис повторного обогащения
+func NewReenricher(repo repository.PersonRepository, enricher Enricher, cfg *config.EnrichmentConfig) *Reenricher {
+	return &Reenricher{repo: repo, enricher: enricher, cfg: cfg}
+}
+
+// Run обходит подходящих под фильтр людей пачками по возрастанию id.
+// progress, если задан, вызывается с промежуточным итогом после каждой пачки.
+func (r *Reenricher) Run(ctx context.Context, opts ReenrichOptions, progress func(ReenrichReport)) (ReenrichReport, error) {
+	report := ReenrichReport{DryRun: opts.DryRun, Results: []ReenrichResult{}}
+	if err := ValidateReenrichFilter(opts.Filter); err != nil {
+		return report, err
+	}
+	slog.Info("Повторное обогащение", "filter", opts.Filter, "dry_run", opts.DryRun)
+
+	// Провайдеры могли улучшить ответы, поэтому кеш не читается, но свежие ответы в него попадают
+	ctx = WithCacheBypass(ctx)
+
+	afterID := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		pageSize := reenrichPageSize
+		if opts.Filter.Limit > 0 {
+			pageSize = min(pageSize, opts.Filter.Limit-report.Processed)
+			if pageSize <= 0 {
+				break
+			}
+		}
+
+		persons, err := r.repo.GetPersonsForReenrichment(opts.Filter, afterID, pageSize)
+		if err != nil {
+			return report, err
+		}
+		if len(persons) == 0 {
+			break
+		}
+		r.reenrichPage(ctx, persons, opts.DryRun, &report)
+		afterID = persons[len(persons)-1].ID
+		if progress != nil {
+			progress(report)
+		}
+	}
+
+	slog.Info("Повторное обогащение завершено", "processed", report.Processed, "changed", report.Changed, "failed", report.Failed)
+	return report, nil
+}
+
+// reenrichPage обогащает пачку людей одним пакетным запросом и сохраняет изменения
+func (r *Reenricher) reenrichPage(ctx context.Context, persons []model.Person, dryRun bool, report *ReenrichReport) {
+	var reqs []EnrichRequest
+	var pending []int
+	for i, person := range persons {
+		req := requestFor(person)
+		req.CountryID = countryFor(r.cfg, person, "")
+		if len(req.Attributes) > 0 {
+			reqs = append(reqs, req)
+			pending = append(pending, i)
+		}
+	}
+	report.Processed += len(persons)
+
+	enrichments, errs := enrichBatch(ctx, r.enricher, reqs)
+	for j, i := range pending {
+		person := persons[i]
+		result := ReenrichResult{PersonID: person.ID, Name: person.Name}
+
+		enrichment, err := enrichments[j], errs[j]
+		flagged := assessConfidence(r.cfg, &enrichment, reqs[j], err).Flagged
+		updated := refresh(person, enrichment, flagged)
+		updated.NormalizedName = reqs[j].Name
+		result.Changes = diffEnrichment(person, updated)
+		if err == nil {
+			updated.EnrichmentStatus = model.EnrichmentComplete
+		} else {
+			result.Error = err.Error()
+		}
+
+		if !dryRun {
+			if updErr := r.repo.UpdateEnrichment(updated); updErr != nil {
+				slog.Error("Ошибка сохранения результатов повторного обогащения", "person", person.ID, "error", updErr)
+				result.Error = updErr.Error()
+			}
+		}
+
+		if result.Error != "" {
+			report.Failed++
+		}
+		if len(result.Changes) > 0 {
+			report.Changed++
+		}
+		if result.Error != "" || len(result.Changes) > 0 {
+			report.Results = append(report.Results, result)
+		}
+	}
+}
+
+// refresh переносит в копию человека только непустые полученные значения и обновляет пометки низкой достоверности
+func refresh(person model.Person, enrichment Enrichment, flagged []string) model.Person {
+	var lowConfidence []string
+	for _, attr := range person.LowConfidence {
+		if !enrichment.known(attr) {
+			lowConfidence = append(lowConfidence, attr)
+		}
+	}
+	for _, attr := range allAttributes {
+		if !enrichment.known(attr) {
+			continue
+		}
+		enrichment.only(EnrichRequest{Attributes: []string{attr}}).apply(&person)
+		if slices.Contains(flagged, attr) {
+			lowConfidence = append(lowConfidence, attr)
+		}
+	}
+	person.LowConfidence = lowConfidence
+	return person
+}
+
+// diffEnrichment перечисляет изменившиеся значения атрибутов
+func diffEnrichment(before, after model.Person) []FieldChange {
+	var changes []FieldChange
+	add := func(attr, oldValue, newValue, source string) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Attribute: attr, Old: oldValue, New: newValue, Source: source})
+		}
+	}
+	age := func(age int) string {
+		if age == 0 {
+			return ""
+		}
+		return strconv.Itoa(age)
+	}
+
+	add(AttributeAge, age(before.Age), age(after.Age), after.AgeSource)
+	add(AttributeGender, before.Gender, after.Gender, after.GenderSource)
+	add(AttributeNationality, before.Nationality, after.Nationality, after.NationalitySource)
+	return changes
+}
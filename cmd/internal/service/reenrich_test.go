@@ -0,0 +1,271 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/config"
+	"TestEffectiveMobile/cmd/internal/model"
+	"TestEffectiveMobile/cmd/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// reenrichRepo репозиторий с людьми persons по возрастанию id; фильтр, кроме числа людей, не применяется
+type reenrichRepo struct {
+	repository.PersonRepository
+	mu      sync.Mutex
+	persons []model.Person
+	failID  int // UpdateEnrichment этого человека завершается ошибкой
+	limits  []int
+	updated []model.Person
+}
+
+func (r *reenrichRepo) GetPersonsForReenrichment(filter model.ReenrichFilter, afterID, limit int) ([]model.Person, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limits = append(r.limits, limit)
+	var persons []model.Person
+	for _, person := range r.persons {
+		if person.ID > afterID && len(persons) < limit {
+			persons = append(persons, person)
+		}
+	}
+	return persons, nil
+}
+
+func (r *reenrichRepo) UpdateEnrichment(person model.Person) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if person.ID == r.failID {
+		return errors.New("ошибка БД")
+	}
+	r.updated = append(r.updated, person)
+	return nil
+}
+
+// updatedIDs возвращает id сохраненных людей
+func (r *reenrichRepo) updatedIDs() []int {
+	var ids []int
+	for _, person := range r.updated {
+		ids = append(ids, person.ID)
+	}
+	return ids
+}
+
+func TestRefresh(t *testing.T) {
+	enriched := model.Person{
+		Name: "Ivan", Age: 30, AgeCount: 100, AgeSource: model.SourceAgify,
+		Gender: "male", GenderProbability: 0.9, GenderSource: model.SourceGenderize,
+		LowConfidence: []string{AttributeAge, AttributeGender},
+	}
+	tests := []struct {
+		name       string
+		enrichment Enrichment
+		flagged    []string
+		want       model.Person
+	}{
+		{
+			name:       "новое значение заменяет прежнее и снимает пометку",
+			enrichment: Enrichment{Age: 35, AgeCount: 200, AgeSource: model.SourceAgify},
+			want: model.Person{
+				Name: "Ivan", Age: 35, AgeCount: 200, AgeSource: model.SourceAgify,
+				Gender: "male", GenderProbability: 0.9, GenderSource: model.SourceGenderize,
+				LowConfidence: []string{AttributeGender},
+			},
+		},
+		{
+			name:       "пустой ответ не стирает значение",
+			enrichment: Enrichment{AgeSource: model.SourceAgify, GenderSource: model.SourceGenderize},
+			want:       enriched,
+		},
+		{
+			name:       "недостоверное значение получает пометку",
+			enrichment: Enrichment{Gender: "female", GenderProbability: 0.55, GenderSource: model.SourceGenderize},
+			flagged:    []string{AttributeGender},
+			want: model.Person{
+				Name: "Ivan", Age: 30, AgeCount: 100, AgeSource: model.SourceAgify,
+				Gender: "female", GenderProbability: 0.55, GenderSource: model.SourceGenderize,
+				LowConfidence: []string{AttributeAge, AttributeGender},
+			},
+		},
+		{
+			name: "национальность переносится вместе с распределением",
+			enrichment: Enrichment{
+				Nationality: "RU", NationalityProbability: 0.8, NationalitySource: model.SourceNationalize,
+				Nationalities: []model.CountryPrediction{{CountryID: "RU", Probability: 0.8}},
+			},
+			want: model.Person{
+				Name: "Ivan", Age: 30, AgeCount: 100, AgeSource: model.SourceAgify,
+				Gender: "male", GenderProbability: 0.9, GenderSource: model.SourceGenderize,
+				Nationality: "RU", NationalityProbability: 0.8, NationalitySource: model.SourceNationalize,
+				Nationalities: []model.CountryPrediction{{CountryID: "RU", Probability: 0.8}},
+				LowConfidence: []string{AttributeAge, AttributeGender},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			person := enriched
+			person.LowConfidence = slices.Clone(enriched.LowConfidence)
+			if got := refresh(person, tt.enrichment, tt.flagged); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("refresh() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffEnrichment(t *testing.T) {
+	before := model.Person{Age: 30, Gender: "male", GenderSource: model.SourceGenderize}
+	tests := []struct {
+		name  string
+		after model.Person
+		want  []FieldChange
+	}{
+		{
+			name:  "без изменений",
+			after: model.Person{Age: 30, AgeSource: model.SourceAgify, Gender: "male", GenderProbability: 0.99, GenderSource: model.SourceGenderize},
+		},
+		{
+			name: "все атрибуты",
+			after: model.Person{
+				Age: 31, AgeSource: model.SourceAgify,
+				Gender: "female", GenderSource: model.SourceGenderize,
+				Nationality: "RU", NationalitySource: model.SourceNationalize,
+			},
+			want: []FieldChange{
+				{Attribute: AttributeAge, Old: "30", New: "31", Source: model.SourceAgify},
+				{Attribute: AttributeGender, Old: "male", New: "female", Source: model.SourceGenderize},
+				{Attribute: AttributeNationality, Old: "", New: "RU", Source: model.SourceNationalize},
+			},
+		},
+		{
+			name:  "удаленный возраст записывается пустой строкой",
+			after: model.Person{Gender: "male", GenderSource: model.SourceGenderize},
+			want:  []FieldChange{{Attribute: AttributeAge, Old: "30", New: ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffEnrichment(before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffEnrichment() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReenricherRun(t *testing.T) {
+	persons := func() []model.Person {
+		return []model.Person{
+			// Данные совпадают с ответом провайдеров
+			{ID: 1, Name: "Ivan", Age: 30, AgeSource: model.SourceAgify, Gender: "male", GenderSource: model.SourceGenderize,
+				Nationality: "RU", NationalitySource: model.SourceNationalize},
+			{ID: 2, Name: "Maria"},
+			// Возраст задан клиентом и не запрашивается
+			{ID: 3, Name: "Petr", Age: 40, AgeSource: model.SourceUser},
+			// Все значения заданы вручную, обогащать нечего
+			{ID: 4, Name: "Anna", Age: 25, AgeSource: model.SourceManual, Gender: "female", GenderSource: model.SourceManual,
+				Nationality: "UA", NationalitySource: model.SourceManual},
+		}
+	}
+	tests := []struct {
+		name        string
+		opts        ReenrichOptions
+		failID      int
+		want        ReenrichReport
+		wantUpdated []int
+		wantResults []int
+	}{
+		{
+			name:        "изменения сохраняются",
+			want:        ReenrichReport{Processed: 4, Changed: 2},
+			wantUpdated: []int{1, 2, 3},
+			wantResults: []int{2, 3},
+		},
+		{
+			name:        "dry run ничего не сохраняет",
+			opts:        ReenrichOptions{DryRun: true},
+			want:        ReenrichReport{DryRun: true, Processed: 4, Changed: 2},
+			wantResults: []int{2, 3},
+		},
+		{
+			name:        "ограничение числа людей",
+			opts:        ReenrichOptions{Filter: model.ReenrichFilter{Limit: 2}},
+			want:        ReenrichReport{Processed: 2, Changed: 1},
+			wantUpdated: []int{1, 2},
+			wantResults: []int{2},
+		},
+		{
+			name:        "ошибка сохранения попадает в отчет",
+			failID:      2,
+			want:        ReenrichReport{Processed: 4, Changed: 2, Failed: 1},
+			wantUpdated: []int{1, 3},
+			wantResults: []int{2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &reenrichRepo{persons: persons(), failID: tt.failID}
+			reenricher := NewReenricher(repo, &countingEnricher{value: testEnrichment()}, &config.EnrichmentConfig{})
+
+			report, err := reenricher.Run(context.Background(), tt.opts, nil)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if report.DryRun != tt.want.DryRun || report.Processed != tt.want.Processed ||
+				report.Changed != tt.want.Changed || report.Failed != tt.want.Failed {
+				t.Errorf("Run() = %+v, want %+v", report, tt.want)
+			}
+			var results []int
+			for _, result := range report.Results {
+				results = append(results, result.PersonID)
+			}
+			if !slices.Equal(results, tt.wantResults) {
+				t.Errorf("results = %v, want %v", results, tt.wantResults)
+			}
+			if updated := repo.updatedIDs(); !slices.Equal(updated, tt.wantUpdated) {
+				t.Errorf("сохранены = %v, want %v", updated, tt.wantUpdated)
+			}
+			for _, person := range repo.updated {
+				if person.EnrichmentStatus != model.EnrichmentComplete || person.NormalizedName == "" {
+					t.Errorf("человек %d сохранен со статусом %q и именем %q", person.ID, person.EnrichmentStatus, person.NormalizedName)
+				}
+			}
+		})
+	}
+}
+
+func TestReenricherRunPages(t *testing.T) {
+	repo := &reenrichRepo{}
+	for id := 1; id <= 2*reenrichPageSize+50; id++ {
+		repo.persons = append(repo.persons, model.Person{ID: id, Name: fmt.Sprintf("name%d", id)})
+	}
+	reenricher := NewReenricher(repo, &countingEnricher{value: testEnrichment()}, &config.EnrichmentConfig{})
+
+	var progress []int
+	report, err := reenricher.Run(context.Background(), ReenrichOptions{DryRun: true}, func(report ReenrichReport) {
+		progress = append(progress, report.Processed)
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.Processed != len(repo.persons) || report.Changed != len(repo.persons) {
+		t.Errorf("Processed, Changed = %d, %d, want %d", report.Processed, report.Changed, len(repo.persons))
+	}
+	if want := []int{reenrichPageSize, 2 * reenrichPageSize, 2*reenrichPageSize + 50}; !slices.Equal(progress, want) {
+		t.Errorf("progress = %v, want %v", progress, want)
+	}
+	// Последний запрос возвращает пустую страницу и завершает обход
+	if len(repo.limits) != 4 {
+		t.Errorf("запросов к БД = %d, want 4", len(repo.limits))
+	}
+
+	// Отмена контекста останавливает обход до следующей пачки
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = reenricher.Run(ctx, ReenrichOptions{DryRun: true}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen возвращается, пока автоматический выключатель провайдера разомкнут
+var ErrCircuitOpen = errors.New("провайдер временно отключен")
+
+// APIError описывает неуспешный HTTP-ответ внешнего API
+type APIError struct {
+	Provider   string
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+// Error реализует интерфейс error
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s вернул статус %d", e.Provider, e.StatusCode)
+}
+
+// Temporary сообщает, имеет ли смысл повторить запрос
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// RetryPolicy задает число попыток и границы экспоненциальной задержки между ними
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// backoff возвращает задержку перед попыткой attempt (начиная с 1) со случайным разбросом
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryable сообщает, стоит ли повторять запрос после ошибки err
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	// Сетевые ошибки и ошибки чтения ответа считаем временными
+	return true
+}
+
+// providerFault сообщает, говорит ли ошибка о неисправности провайдера. Истекший дедлайн считается
+// отказом: зависший провайдер иначе никогда не разомкнул бы выключатель. Отмена запроса вызывающим
+// и ошибки клиента (4xx, кроме 429) о здоровье провайдера не говорят.
+func providerFault(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	// Дедлайн контекста, таймаут HTTP-клиента, сетевые ошибки и ошибки чтения ответа
+	return true
+}
+
+// parseRetryAfter разбирает заголовок Retry-After в секундах или в формате HTTP-даты
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// sleep ожидает delay или отмены контекста
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker размыкается после серии отказов провайдера и пропускает
+// пробный запрос по истечении openTimeout
+type CircuitBreaker struct {
+	mu          sync.Mutex
+	name        string
+	threshold   int
+	openTimeout time.Duration
+	failures    int
+	state       breakerState
+	openedAt    time.Time
+	probing     bool
+}
+
+// NewCircuitBreaker создает выключатель, размыкающийся после threshold отказов подряд
+func NewCircuitBreaker(name string, threshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{name: name, threshold: threshold, openTimeout: openTimeout}
+}
+
+// Allow возвращает ErrCircuitOpen, если запрос к провайдеру сейчас выполнять нельзя
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		slog.Info("Пробный запрос к провайдеру", "provider", b.name)
+		return nil
+	case breakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success фиксирует успешный запрос и замыкает выключатель
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != breakerClosed {
+		slog.Info("Провайдер снова доступен", "provider", b.name)
+	}
+	b.state, b.failures, b.probing = breakerClosed, 0, false
+}
+
+// Failure фиксирует отказ провайдера
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		if b.state != breakerOpen {
+			slog.Warn("Провайдер отключен после серии ошибок", "provider", b.name, "failures", b.failures)
+		}
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// State возвращает текущее состояние выключателя: closed, open или half-open
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
+
+// Release освобождает пробный запрос, результат которого не говорит о здоровье провайдера
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
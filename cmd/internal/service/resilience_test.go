@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedAPI отвечает статусами statuses по порядку, затем 200; Retry-After передается с каждым ответом 429
+type scriptedAPI struct {
+	statuses   []int
+	retryAfter string
+	calls      atomic.Int32
+}
+
+func (s *scriptedAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	call := int(s.calls.Add(1))
+	if call <= len(s.statuses) && s.statuses[call-1] != http.StatusOK {
+		if s.statuses[call-1] == http.StatusTooManyRequests && s.retryAfter != "" {
+			w.Header().Set("Retry-After", s.retryAfter)
+		}
+		w.WriteHeader(s.statuses[call-1])
+		return
+	}
+	w.Write([]byte(`{"name": "ivan", "age": 30, "count": 100}`))
+}
+
+// newScriptedClient создает клиента к scriptedAPI с быстрыми повторами
+func newScriptedClient(t *testing.T, api *scriptedAPI, attempts int, breaker *CircuitBreaker) *APIClient {
+	t.Helper()
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	retry := RetryPolicy{MaxAttempts: attempts, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	return NewAPIClient("agify", server.URL, "", server.Client(), retry, breaker, nil)
+}
+
+func TestAPIClientRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		retryAfter string
+		attempts   int
+		timeout    time.Duration
+		wantCalls  int32
+		wantStatus int // 0 — запрос успешен
+		minElapsed time.Duration
+	}{
+		{
+			name:      "временные ошибки повторяются",
+			statuses:  []int{http.StatusInternalServerError, http.StatusServiceUnavailable},
+			attempts:  3,
+			wantCalls: 3,
+		},
+		{
+			name:       "попытки исчерпаны",
+			statuses:   []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			attempts:   3,
+			wantCalls:  3,
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:       "ошибка клиента не повторяется",
+			statuses:   []int{http.StatusUnprocessableEntity},
+			attempts:   3,
+			wantCalls:  1,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Retry-After увеличивает задержку",
+			statuses:   []int{http.StatusTooManyRequests},
+			retryAfter: "1",
+			attempts:   2,
+			wantCalls:  2,
+			minElapsed: time.Second,
+		},
+		{
+			name:       "Retry-After за дедлайном не ждется",
+			statuses:   []int{http.StatusTooManyRequests},
+			retryAfter: "30",
+			attempts:   3,
+			timeout:    time.Second,
+			wantCalls:  1,
+			wantStatus: http.StatusTooManyRequests,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &scriptedAPI{statuses: tt.statuses, retryAfter: tt.retryAfter}
+			client := newScriptedClient(t, api, tt.attempts, NewCircuitBreaker("agify", 10, time.Minute))
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			var target map[string]any
+			err := client.GetJSON(ctx, url.Values{"name": {"ivan"}}, &target)
+			elapsed := time.Since(start)
+
+			var apiErr *APIError
+			switch {
+			case tt.wantStatus == 0 && err != nil:
+				t.Fatalf("GetJSON() error = %v, want nil", err)
+			case tt.wantStatus != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus):
+				t.Fatalf("GetJSON() error = %v, want status %d", err, tt.wantStatus)
+			}
+			if calls := api.calls.Load(); calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if elapsed < tt.minElapsed {
+				t.Errorf("elapsed = %s, want at least %s", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
+
+func TestAPIClientBreaker(t *testing.T) {
+	api := &scriptedAPI{statuses: []int{http.StatusInternalServerError, http.StatusInternalServerError}}
+	breaker := NewCircuitBreaker("agify", 2, 50*time.Millisecond)
+	client := newScriptedClient(t, api, 1, breaker)
+	get := func() error {
+		var target map[string]any
+		return client.GetJSON(context.Background(), url.Values{"name": {"ivan"}}, &target)
+	}
+
+	for range 2 {
+		if err := get(); err == nil {
+			t.Fatal("GetJSON() error = nil, want 500")
+		}
+	}
+	if state := breaker.State(); state != "open" {
+		t.Fatalf("state = %s, want open", state)
+	}
+
+	// Разомкнутый выключатель не пропускает запросы к провайдеру
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetJSON() error = %v, want ErrCircuitOpen", err)
+	}
+	if calls := api.calls.Load(); calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+
+	// После openTimeout пробный запрос проходит и замыкает выключатель
+	time.Sleep(60 * time.Millisecond)
+	if err := get(); err != nil {
+		t.Fatalf("GetJSON() error = %v, want nil", err)
+	}
+	if state := breaker.State(); state != "closed" {
+		t.Errorf("state = %s, want closed", state)
+	}
+}
+
+func TestAPIClientBreakerOpensOnTimeouts(t *testing.T) {
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(hanging.Close)
+	breaker := NewCircuitBreaker("agify", 2, time.Minute)
+	client := NewAPIClient("agify", hanging.URL, "", hanging.Client(), RetryPolicy{MaxAttempts: 1}, breaker, nil)
+	get := func(ctx context.Context) error {
+		var target map[string]any
+		return client.GetJSON(ctx, url.Values{"name": {"ivan"}}, &target)
+	}
+
+	// Отмена вызывающим не считается отказом провайдера
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := get(canceled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetJSON() error = %v, want context.Canceled", err)
+	}
+	if state := breaker.State(); state != "closed" {
+		t.Fatalf("state после отмены = %s, want closed", state)
+	}
+
+	for range 2 {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		err := get(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("GetJSON() error = %v, want context.DeadlineExceeded", err)
+		}
+	}
+	if state := breaker.State(); state != "open" {
+		t.Fatalf("state = %s, want open", state)
+	}
+	if err := get(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("GetJSON() error = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestAPIClientBreakerIgnoresClientErrors(t *testing.T) {
+	statuses := []int{http.StatusNotFound, http.StatusNotFound, http.StatusNotFound}
+	breaker := NewCircuitBreaker("agify", 2, time.Minute)
+	client := newScriptedClient(t, &scriptedAPI{statuses: statuses}, 1, breaker)
+	for range statuses {
+		var target map[string]any
+		client.GetJSON(context.Background(), url.Values{"name": {"ivan"}}, &target)
+	}
+	if state := breaker.State(); state != "closed" {
+		t.Errorf("state = %s, want closed", state)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"-1", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), 8 * time.Second, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s..%s", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
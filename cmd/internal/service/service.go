@@ -1,147 +1,364 @@
 package service
 
 import (
+	"TestEffectiveMobile/cmd/internal/config"
 	"TestEffectiveMobile/cmd/internal/model"
 	"TestEffectiveMobile/cmd/internal/repository"
-	"encoding/json"
-	"fmt"
+	"context"
+	"errors"
 	"log/slog"
-	"net/http"
+	"strconv"
+	"time"
+)
+
+// Доменные ошибки сервиса: человека нет, данные конфликтуют с сохраненными, данные некорректны.
+// Репозиторий возвращает те же значения, поэтому их можно проверять через errors.Is на любом уровне.
+var (
+	ErrNotFound   = model.ErrNotFound
+	ErrConflict   = model.ErrConflict
+	ErrValidation = model.ErrValidation
+	// ErrPreconditionFailed возвращается при изменении человека, версия которого не совпала с ожидаемой
+	ErrPreconditionFailed = model.ErrPreconditionFailed
 )
 
 // Интерфейс сервиса для работы с людьми
 type PersonService interface {
-	AddPerson(person model.Person) error
-	GetPersons(page, limit int, name, gender, nationality string) ([]model.Person, error)
-	UpdatePerson(id int, person model.Person) error
-	DeletePerson(id int) error
+	AddPerson(ctx context.Context, person model.Person, countryHint string) (model.EnrichmentReport, error)
+	AddPersons(ctx context.Context, persons []model.Person, countryHint string) []AddResult
+	GetPerson(id int) (*model.Person, error)
+	GetPersons(filter model.PersonFilter, page model.PageRequest) (model.PersonPage, error)
+	UpdatePerson(id, version int, person model.Person) (int, error)
+	PatchPerson(ctx context.Context, id, version int, patch PersonPatch, reenrich bool) (*model.Person, error)
+	DeletePerson(id, version int) error
+	GetEnrichmentJobs(personID int) ([]model.EnrichmentJob, error)
 }
 
 // Реализация сервиса для работы с людьми
 type PersonServiceImpl struct {
-	repo repository.PersonRepository
+	repo     repository.PersonRepository
+	jobs     repository.JobRepository
+	enricher Enricher
+	cfg      *config.EnrichmentConfig
 }
 
 // Конструктор для создания нового сервиса
-func NewPersonService(repo repository.PersonRepository) *PersonServiceImpl {
-	return &PersonServiceImpl{repo: repo}
+func NewPersonService(repo repository.PersonRepository, jobs repository.JobRepository, enricher Enricher, cfg *config.EnrichmentConfig) *PersonServiceImpl {
+	return &PersonServiceImpl{repo: repo, jobs: jobs, enricher: enricher, cfg: cfg}
 }
 
-// Добавление нового человека с обогащением данных из внешних API
-func (s *PersonServiceImpl) AddPerson(person model.Person) error {
-	slog.Info("Получение данных для имени: ", person.Name)
+// Добавление нового человека с обогащением данных из внешних API.
+// Значения, переданные клиентом, сохраняются как есть, обогащаются только недостающие.
+// Поведение при ошибках обогащения определяется политикой из конфигурации,
+// а значения ниже порогов уверенности отбрасываются или помечаются.
+// countryHint уточняет страну для возраста и пола и может быть пустым.
+func (s *PersonServiceImpl) AddPerson(ctx context.Context, person model.Person, countryHint string) (model.EnrichmentReport, error) {
+	slog.Info("Получение данных для имени", "name", person.Name, "policy", s.cfg.Policy, "country", countryHint)
+
+	if err := validatePerson(person); err != nil {
+		return model.EnrichmentReport{}, err
+	}
+	person.NormalizedName = NormalizeName(person.Name)
+	markProvided(&person, model.SourceUser)
+	req := requestFor(person)
+	req.CountryID = countryFor(s.cfg, person, countryHint)
+
+	if s.cfg.Policy == config.PolicyAsync && len(req.Attributes) > 0 {
+		return s.addPersonAsync(person, req)
+	}
 
 	// Обогащение данными из внешних API
-	age, err := getAge(person.Name)
+	var enrichment Enrichment
+	var err error
+	if len(req.Attributes) > 0 {
+		enrichment, err = s.enrich(ctx, req)
+	}
+	return s.saveEnriched(person, req, enrichment, err)
+}
 
-	if err != nil {
-		return err
+// AddResult результат добавления одного человека при массовой загрузке
+type AddResult struct {
+	Report model.EnrichmentReport
+	Err    error
+}
+
+// AddPersons добавляет людей по тем же правилам, что и AddPerson, но обогащает их одной пачкой:
+// одинаковые имена запрашиваются один раз, имена отправляются во внешние API группами.
+// Ошибка одного человека не мешает добавлению остальных.
+func (s *PersonServiceImpl) AddPersons(ctx context.Context, persons []model.Person, countryHint string) []AddResult {
+	slog.Info("Массовое добавление людей", "count", len(persons), "policy", s.cfg.Policy, "country", countryHint)
+
+	results := make([]AddResult, len(persons))
+	var reqs []EnrichRequest
+	var pending []int
+	for i := range persons {
+		if err := validatePerson(persons[i]); err != nil {
+			results[i].Err = err
+			continue
+		}
+		persons[i].NormalizedName = NormalizeName(persons[i].Name)
+		markProvided(&persons[i], model.SourceUser)
+		req := requestFor(persons[i])
+		req.CountryID = countryFor(s.cfg, persons[i], countryHint)
+
+		switch {
+		case s.cfg.Policy == config.PolicyAsync && len(req.Attributes) > 0:
+			results[i].Report, results[i].Err = s.addPersonAsync(persons[i], req)
+		case len(req.Attributes) == 0:
+			results[i].Report, results[i].Err = s.saveEnriched(persons[i], req, Enrichment{}, nil)
+		default:
+			reqs = append(reqs, req)
+			pending = append(pending, i)
+		}
 	}
-	fmt.Println("Полученное имя", age)
 
-	gender, err := getGender(person.Name)
-	if err != nil {
-		return err
+	// Таймаут из конфигурации рассчитан на одного человека, поэтому каждый запрос
+	// пачки ограничивается только таймаутом HTTP-клиента и контекстом вызова
+	enrichments, errs := enrichBatch(ctx, s.enricher, reqs)
+	for j, i := range pending {
+		results[i].Report, results[i].Err = s.saveEnriched(persons[i], reqs[j], enrichments[j], errs[j])
 	}
+	return results
+}
 
-	nationality, err := getNationality(person.Name)
+// saveEnriched применяет результат обогащения с учетом политики и порогов уверенности и сохраняет человека
+func (s *PersonServiceImpl) saveEnriched(person model.Person, req EnrichRequest, enrichment Enrichment, err error) (model.EnrichmentReport, error) {
+	person.EnrichmentStatus = model.EnrichmentComplete
 	if err != nil {
-		return err
+		if s.cfg.Policy == config.PolicyStrict && !s.degradable(err) {
+			slog.Error("Ошибка обогащения данных", "name", person.Name, "error", err)
+			return model.EnrichmentReport{}, err
+		}
+		slog.Warn("Человек сохраняется без части данных обогащения", "name", person.Name, "error", err)
+		person.EnrichmentStatus = model.EnrichmentFailed
 	}
+	report := assessConfidence(s.cfg, &enrichment, req, err)
+	enrichment.apply(&person)
+	person.LowConfidence = report.Flagged
 
 	// Сохранение в базе данных
-	err = s.repo.SavePerson(person.Name, person.Surname, person.Patronymic, age, gender, nationality)
+	id, err := s.repo.SavePerson(person)
 	if err != nil {
-		slog.Error("Ошибка сохранения человека: ", err)
-		return err
+		slog.Error("Ошибка сохранения человека", "error", err)
+		return model.EnrichmentReport{}, err
 	}
-	slog.Info("Человек успешно добавлен в базу данных.")
-	return nil
-}
+	slog.Info("Человек успешно добавлен в базу данных.", "id", id, "rejected", report.Rejected, "flagged", report.Flagged)
 
-func (s *PersonServiceImpl) GetPersons(page, limit int, name, gender, nationality string) ([]model.Person, error) {
-	slog.Info("Получение людей с фильтрами: ", name, gender, nationality)
+	report.PersonID, report.Status = id, person.EnrichmentStatus
+	return report, nil
+}
 
-	// Валидация параметров пагинации
-	if page <= 0 {
-		page = 1
+// addPersonAsync сохраняет человека сразу и ставит задание на обогащение в очередь.
+// Человек и задание сохраняются вместе, чтобы не остаться в статусе pending без задания.
+func (s *PersonServiceImpl) addPersonAsync(person model.Person, req EnrichRequest) (model.EnrichmentReport, error) {
+	person.EnrichmentStatus = model.EnrichmentPending
+	id, err := s.repo.SavePersonWithJob(person, req.CountryID)
+	if err != nil {
+		slog.Error("Ошибка сохранения человека с заданием на обогащение", "error", err)
+		return model.EnrichmentReport{}, err
 	}
-	if limit <= 0 {
-		limit = 10
+	slog.Info("Человек добавлен в базу данных, обогащение поставлено в очередь", "id", id)
+
+	report := newEnrichmentReport()
+	report.PersonID, report.Status = id, person.EnrichmentStatus
+	for _, attr := range allAttributes {
+		if !req.wants(attr) {
+			report.Provided = append(report.Provided, attr)
+		}
 	}
+	return report, nil
+}
 
-	// Получаем людей из репозитория с фильтрами
-	return s.repo.GetAllPersons(page, limit, name, gender, nationality)
+// enrich обогащает данные по имени с учетом таймаута из конфигурации
+func (s *PersonServiceImpl) enrich(ctx context.Context, req EnrichRequest) (Enrichment, error) {
+	return enrichWithTimeout(ctx, s.enricher, s.cfg.Timeout, req)
 }
 
-// Обновление данных о человеке
-func (s *PersonServiceImpl) UpdatePerson(id int, person model.Person) error {
-	return s.repo.UpdatePerson(id, person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality)
+// enrichWithTimeout ограничивает обогащение таймаутом поверх дедлайна ctx
+func enrichWithTimeout(ctx context.Context, enricher Enricher, timeout time.Duration, req EnrichRequest) (Enrichment, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return enricher.Enrich(ctx, req)
 }
 
-// Удаление человека по ID
-func (s *PersonServiceImpl) DeletePerson(id int) error {
-	return s.repo.DeletePerson(id)
+// countryFor выбирает страну для уточнения возраста и пола: подсказку из запроса,
+// а без нее национальность, заданную клиентом или оператором
+func countryFor(cfg *config.EnrichmentConfig, person model.Person, hint string) string {
+	if cfg.CountryStrategy == config.CountryStrategyNone {
+		return ""
+	}
+	if hint != "" {
+		return hint
+	}
+	if person.NationalitySource == model.SourceUser || person.NationalitySource == model.SourceManual {
+		return person.Nationality
+	}
+	return ""
 }
 
-// Вспомогательные функции для получения данных из внешних API
+// markProvided помечает заданные значения возраста, пола и национальности источником source.
+// Служебные поля обогащения из входных данных не принимаются.
+func markProvided(person *model.Person, source string) {
+	person.AgeCount, person.GenderProbability, person.NationalityProbability = 0, 0, 0
+	person.Nationalities, person.LowConfidence = nil, nil
+	person.AgeSource, person.GenderSource, person.NationalitySource = "", "", ""
 
-func getAge(name string) (int, error) {
-	resp, err := http.Get(fmt.Sprintf("https://api.agify.io/?name=%s", name))
-	if err != nil {
-		slog.Error("Ошибка получения возраста: ", err)
-		return 0, err
+	if person.Age > 0 {
+		person.AgeSource = source
+	}
+	if person.Gender != "" {
+		person.GenderSource = source
+	}
+	if person.Nationality != "" {
+		person.NationalitySource = source
 	}
-	defer resp.Body.Close()
+}
 
-	var person model.Person
-	if err := json.NewDecoder(resp.Body).Decode(&person); err != nil {
-		slog.Error("Ошибка декодирования ответа по возрасту: ", err)
-		return 0, err
+// requestFor возвращает запрос на обогащение атрибутов, значения которых не заданы клиентом или оператором.
+// Во внешние API передается нормализованное имя, в БД остается исходное написание.
+func requestFor(person model.Person) EnrichRequest {
+	req := EnrichRequest{Name: NormalizeName(person.Name), Attributes: []string{}}
+	sources := map[string]string{
+		AttributeAge:         person.AgeSource,
+		AttributeGender:      person.GenderSource,
+		AttributeNationality: person.NationalitySource,
+	}
+	for _, attr := range allAttributes {
+		if source := sources[attr]; source != model.SourceUser && source != model.SourceManual {
+			req.Attributes = append(req.Attributes, attr)
+		}
+	}
+	return req
+}
+
+// degradable сообщает, можно ли сохранить человека несмотря на ошибку обогащения:
+// в режиме degrade допускается, что часть провайдеров отключена выключателем
+func (s *PersonServiceImpl) degradable(err error) bool {
+	if s.cfg.CircuitOpenMode != config.CircuitOpenDegrade {
+		return false
+	}
+	var enrichErr *EnrichmentError
+	if !errors.As(err, &enrichErr) {
+		return false
 	}
+	for _, attrErr := range enrichErr.Failed {
+		if !errors.Is(attrErr, ErrCircuitOpen) {
+			return false
+		}
+	}
+	return true
+}
 
-	return person.Age, nil
+// Получение человека по ID; если человека нет, возвращается ErrNotFound
+func (s *PersonServiceImpl) GetPerson(id int) (*model.Person, error) {
+	return s.repo.GetPerson(id)
 }
 
-func getGender(name string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://api.genderize.io/?name=%s", name))
+// Размер страницы списка людей по умолчанию и максимальный
+const (
+	defaultPageLimit = 10
+	MaxPageLimit     = 100
+)
+
+// GetPersons возвращает страницу людей. Размер страницы ограничен MaxPageLimit;
+// если есть следующая страница, в Next возвращаются значения сортировки последнего человека.
+// Общее число людей считается, только если запрошено в page.Total: на глубоких страницах
+// подсчет дороже самой выборки. На последней странице оно вычисляется без отдельного запроса к БД.
+func (s *PersonServiceImpl) GetPersons(filter model.PersonFilter, page model.PageRequest) (model.PersonPage, error) {
+	slog.Info("Получение людей с фильтрами", "filter", filter, "sort", page.Sort, "after", page.After)
+	filter.NormalizedName = NormalizeName(filter.Name)
+
+	// Валидация параметров пагинации
+	if page.Page <= 0 {
+		page.Page = 1
+	}
+	if page.Limit <= 0 {
+		page.Limit = defaultPageLimit
+	}
+	page.Limit = min(page.Limit, MaxPageLimit)
+
+	persons, more, err := s.repo.GetAllPersons(filter, page)
 	if err != nil {
-		slog.Error("Ошибка получения пола: ", err)
-		return "", err
+		return model.PersonPage{}, err
 	}
-	defer resp.Body.Close()
 
-	var person model.Person
-	if err := json.NewDecoder(resp.Body).Decode(&person); err != nil {
-		slog.Error("Ошибка декодирования ответа по полу: ", err)
-		return "", err
+	result := model.PersonPage{Items: persons, Page: page.Page, Limit: page.Limit}
+	if more {
+		result.Next = sortValues(persons[len(persons)-1], model.StableSort(page.Sort))
 	}
 
-	return person.Gender, nil
-}
+	if page.Total == "" || page.Total == model.TotalNone {
+		return result, nil
+	}
+	result.TotalKnown = true
 
-func getNationality(name string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://api.nationalize.io/?name=%s", name))
-	if err != nil {
-		slog.Error("Ошибка получения национальности: ", "error", err)
-		return "", err
+	// Непустая последняя страница (или пустая первая) определяет общее число сама
+	if page.After == nil && !more && (len(persons) > 0 || page.Page == 1) {
+		result.Total = (page.Page-1)*page.Limit + len(persons)
+		return result, nil
+	}
+	estimate := page.Total == model.TotalEstimated
+	if result.Total, err = s.repo.CountPersons(filter, estimate); err != nil {
+		return model.PersonPage{}, err
+	}
+	if estimate {
+		// Оценка по статистике не может быть меньше уже увиденного
+		seen := len(persons)
+		if page.After == nil {
+			seen += (page.Page - 1) * page.Limit
+		}
+		if more {
+			seen++
+		}
+		result.Total, result.TotalEstimated = max(result.Total, seen), true
 	}
-	defer resp.Body.Close()
+	return result, nil
+}
 
-	var data model.NationalizeResponse
-	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		slog.Error("Ошибка декодирования ответа по национальности",
-			slog.String("error", err.Error()),
-		)
-		return "", err
+// sortValues возвращает значения полей сортировки человека в том виде, в каком их сравнивает БД
+func sortValues(person model.Person, sort []model.SortField) []string {
+	values := make([]string, len(sort))
+	for i, field := range sort {
+		switch field.Field {
+		case "id":
+			values[i] = strconv.Itoa(person.ID)
+		case "name":
+			values[i] = person.Name
+		case "surname":
+			values[i] = person.Surname
+		case "patronymic":
+			values[i] = person.Patronymic
+		case "age":
+			values[i] = strconv.Itoa(person.Age)
+		case "gender":
+			values[i] = person.Gender
+		case "nationality":
+			values[i] = person.Nationality
+		}
 	}
+	return values
+}
 
-	if len(data.Country) == 0 {
-		slog.Info("Не удалось определить национальность для имени", "name", name)
-		return "", nil
+// Обновление данных о человеке; заданные значения считаются исправленными оператором.
+// Если version не 0, обновляется только эта версия человека. Возвращает новую версию.
+func (s *PersonServiceImpl) UpdatePerson(id, version int, person model.Person) (int, error) {
+	if err := validatePerson(person); err != nil {
+		return 0, err
 	}
+	person.ID, person.Version = id, version
+	person.NormalizedName = NormalizeName(person.Name)
+	markProvided(&person, model.SourceManual)
+	return s.repo.UpdatePerson(person)
+}
 
-	nationality := data.Country[0].CountryID
-	slog.Info("Определена национальность", "name", name, "nationality", nationality)
-	return nationality, nil
+// Удаление человека по ID; если version не 0, удаляется только эта версия человека
+func (s *PersonServiceImpl) DeletePerson(id, version int) error {
+	return s.repo.DeletePerson(id, version)
+}
+
+// Получение заданий на обогащение человека; если человека нет, возвращается ErrNotFound
+func (s *PersonServiceImpl) GetEnrichmentJobs(personID int) ([]model.EnrichmentJob, error) {
+	if _, err := s.repo.GetPerson(personID); err != nil {
+		return nil, err
+	}
+	return s.jobs.GetEnrichmentJobs(personID)
 }
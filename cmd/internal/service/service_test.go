@@ -0,0 +1,121 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/config"
+	"TestEffectiveMobile/cmd/internal/model"
+	"TestEffectiveMobile/cmd/internal/repository"
+	"testing"
+)
+
+// pageRepo репозиторий с count людьми, выдаваемыми по номеру страницы; остальные методы не вызываются
+type pageRepo struct {
+	repository.PersonRepository
+	count    int
+	estimate int // Оценка числа людей по статистике
+	pages    []model.PageRequest
+	counts   int
+}
+
+func (r *pageRepo) GetAllPersons(filter model.PersonFilter, page model.PageRequest) ([]model.Person, bool, error) {
+	r.pages = append(r.pages, page)
+	var persons []model.Person
+	for id := (page.Page-1)*page.Limit + 1; id <= min(page.Page*page.Limit, r.count); id++ {
+		persons = append(persons, model.Person{ID: id})
+	}
+	return persons, page.Page*page.Limit < r.count, nil
+}
+
+func (r *pageRepo) CountPersons(filter model.PersonFilter, estimate bool) (int, error) {
+	r.counts++
+	if estimate {
+		return r.estimate, nil
+	}
+	return r.count, nil
+}
+
+func TestGetPersonsPage(t *testing.T) {
+	tests := []struct {
+		name          string
+		count         int
+		estimate      int
+		page          model.PageRequest
+		wantLimit     int
+		wantItems     int
+		wantTotal     int
+		wantKnown     bool
+		wantEstimated bool
+		wantCounts    int
+	}{
+		{
+			name:      "размер страницы по умолчанию",
+			count:     50,
+			wantLimit: 10, wantItems: 10,
+		},
+		{
+			name:      "размер страницы ограничен",
+			count:     250,
+			page:      model.PageRequest{Limit: 500},
+			wantLimit: MaxPageLimit, wantItems: MaxPageLimit,
+		},
+		{
+			name:      "без подсчета",
+			count:     50,
+			page:      model.PageRequest{Page: 2, Limit: 10, Total: model.TotalNone},
+			wantLimit: 10, wantItems: 10,
+		},
+		{
+			name:      "точный подсчет",
+			count:     50,
+			page:      model.PageRequest{Page: 2, Limit: 10, Total: model.TotalExact},
+			wantLimit: 10, wantItems: 10,
+			wantTotal: 50, wantKnown: true, wantCounts: 1,
+		},
+		{
+			name:      "последняя страница считается без запроса",
+			count:     45,
+			page:      model.PageRequest{Page: 5, Limit: 10, Total: model.TotalExact},
+			wantLimit: 10, wantItems: 5,
+			wantTotal: 45, wantKnown: true,
+		},
+		{
+			name:      "оценка",
+			count:     50,
+			estimate:  48,
+			page:      model.PageRequest{Page: 2, Limit: 10, Total: model.TotalEstimated},
+			wantLimit: 10, wantItems: 10,
+			wantTotal: 48, wantKnown: true, wantEstimated: true, wantCounts: 1,
+		},
+		{
+			name:      "оценка не меньше увиденного",
+			count:     50,
+			estimate:  3,
+			page:      model.PageRequest{Page: 2, Limit: 10, Total: model.TotalEstimated},
+			wantLimit: 10, wantItems: 10,
+			wantTotal: 21, wantKnown: true, wantEstimated: true, wantCounts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &pageRepo{count: tt.count, estimate: tt.estimate}
+			s := NewPersonService(repo, nil, nil, &config.EnrichmentConfig{})
+
+			got, err := s.GetPersons(model.PersonFilter{}, tt.page)
+			if err != nil {
+				t.Fatalf("GetPersons() error = %v", err)
+			}
+			if got.Limit != tt.wantLimit || repo.pages[0].Limit != tt.wantLimit {
+				t.Errorf("Limit = %d, в репозитории %d, want %d", got.Limit, repo.pages[0].Limit, tt.wantLimit)
+			}
+			if len(got.Items) != tt.wantItems {
+				t.Errorf("len(Items) = %d, want %d", len(got.Items), tt.wantItems)
+			}
+			if got.Total != tt.wantTotal || got.TotalKnown != tt.wantKnown || got.TotalEstimated != tt.wantEstimated {
+				t.Errorf("Total = %d, %v, %v, want %d, %v, %v",
+					got.Total, got.TotalKnown, got.TotalEstimated, tt.wantTotal, tt.wantKnown, tt.wantEstimated)
+			}
+			if repo.counts != tt.wantCounts {
+				t.Errorf("CountPersons вызван %d раз, want %d", repo.counts, tt.wantCounts)
+			}
+		})
+	}
+}
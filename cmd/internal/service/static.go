@@ -0,0 +1,48 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"context"
+)
+
+// StaticProvider возвращает заранее заданные значения без обращения к сети.
+// Используется в тестах и при локальной разработке.
+type StaticProvider struct {
+	AgeValue         AgeResult
+	GenderValue      GenderResult
+	NationalityValue NationalityResult
+	Err              error
+}
+
+// NewStaticProvider создает провайдера с фиксированными ответами, в которых он полностью уверен
+func NewStaticProvider(age int, gender, nationality string) *StaticProvider {
+	p := &StaticProvider{
+		AgeValue:         AgeResult{Age: age, Count: 1, Source: model.SourceStatic},
+		GenderValue:      GenderResult{Gender: gender, Probability: 1, Count: 1, Source: model.SourceStatic},
+		NationalityValue: NationalityResult{Source: model.SourceStatic},
+	}
+	if nationality != "" {
+		p.NationalityValue.Countries = []model.CountryPrediction{{CountryID: nationality, Probability: 1}}
+	}
+	return p
+}
+
+// Age реализует интерфейс AgeProvider
+func (p *StaticProvider) Age(_ context.Context, _, _ string) (AgeResult, error) {
+	return p.AgeValue, p.Err
+}
+
+// Gender реализует интерфейс GenderProvider
+func (p *StaticProvider) Gender(_ context.Context, _, _ string) (GenderResult, error) {
+	return p.GenderValue, p.Err
+}
+
+// Nationality реализует интерфейс NationalityProvider
+func (p *StaticProvider) Nationality(_ context.Context, _ string) (NationalityResult, error) {
+	return p.NationalityValue, p.Err
+}
+
+// NewStaticEnricher создает обогатитель, все провайдеры которого статические
+func NewStaticEnricher(p *StaticProvider) *CompositeEnricher {
+	return NewCompositeEnricher(p, p, p)
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/model"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ограничения на данные человека; длины совпадают с размерами столбцов в БД
+const (
+	maxNameLength = 100
+	MaxAge        = 150
+)
+
+// validatePerson проверяет данные человека от клиента или оператора и возвращает ошибку, обернутую в ErrValidation
+func validatePerson(person model.Person) error {
+	fields := []struct{ name, value string }{
+		{"name", person.Name}, {"surname", person.Surname}, {"patronymic", person.Patronymic},
+	}
+	for _, field := range fields {
+		if field.name != "patronymic" && strings.TrimSpace(field.value) == "" {
+			return fmt.Errorf("%w: поле %s обязательно", ErrValidation, field.name)
+		}
+		if utf8.RuneCountInString(field.value) > maxNameLength {
+			return fmt.Errorf("%w: поле %s длиннее %d символов", ErrValidation, field.name, maxNameLength)
+		}
+	}
+	if person.Age < 0 || person.Age > MaxAge {
+		return fmt.Errorf("%w: возраст должен быть от 0 до %d", ErrValidation, MaxAge)
+	}
+	if person.Gender != "" && person.Gender != "male" && person.Gender != "female" {
+		return fmt.Errorf("%w: пол должен быть male или female", ErrValidation)
+	}
+	if person.Nationality != "" && !isCountryCode(person.Nationality) {
+		return fmt.Errorf("%w: национальность должна быть кодом страны ISO 3166-1 (RU)", ErrValidation)
+	}
+	return nil
+}
+
+// isCountryCode сообщает, является ли строка двухбуквенным кодом страны в верхнем регистре
+func isCountryCode(s string) bool {
+	return len(s) == 2 && s[0] >= 'A' && s[0] <= 'Z' && s[1] >= 'A' && s[1] <= 'Z'
+}
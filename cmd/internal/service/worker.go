@@ -0,0 +1,122 @@
+package service
+
+import (
+	"TestEffectiveMobile/cmd/internal/config"
+	"TestEffectiveMobile/cmd/internal/model"
+	"TestEffectiveMobile/cmd/internal/repository"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// EnrichmentWorker обрабатывает очередь заданий на обогащение из PostgreSQL.
+// Несколько воркеров, в том числе в разных экземплярах приложения, могут работать
+// с одной очередью: задания забираются через SELECT ... FOR UPDATE SKIP LOCKED.
+type EnrichmentWorker struct {
+	persons  repository.PersonRepository
+	jobs     repository.JobRepository
+	enricher Enricher
+	cfg      *config.EnrichmentConfig
+}
+
+// NewEnrichmentWorker создает пул воркеров обогащения
+func NewEnrichmentWorker(persons repository.PersonRepository, jobs repository.JobRepository, enricher Enricher, cfg *config.EnrichmentConfig) *EnrichmentWorker {
+	return &EnrichmentWorker{persons: persons, jobs: jobs, enricher: enricher, cfg: cfg}
+}
+
+// Run запускает cfg.WorkerCount воркеров и блокируется до отмены контекста
+func (w *EnrichmentWorker) Run(ctx context.Context) {
+	slog.Info("Запуск воркеров обогащения", "count", w.cfg.WorkerCount)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.cfg.WorkerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// loop забирает задания по одному, пока они есть, и ждет между опросами пустой очереди
+func (w *EnrichmentWorker) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		jobs, err := w.jobs.ClaimEnrichmentJobs(1, w.cfg.JobLease)
+		if err != nil {
+			slog.Error("Ошибка получения заданий на обогащение", "error", err)
+		}
+		for _, job := range jobs {
+			w.process(ctx, job)
+		}
+		if len(jobs) > 0 {
+			continue
+		}
+		if sleep(ctx, w.cfg.WorkerPollInterval) != nil {
+			return
+		}
+	}
+}
+
+// process выполняет одно задание: обогащает человека, а при ошибке
+// откладывает задание или переносит его в dead-letter после исчерпания попыток
+func (w *EnrichmentWorker) process(ctx context.Context, job model.EnrichmentJob) {
+	person, err := w.persons.GetPerson(job.PersonID)
+	if err != nil {
+		slog.Error("Ошибка получения человека для обогащения", "job", job.ID, "person", job.PersonID, "error", err)
+		w.fail(job, err)
+		return
+	}
+
+	// Значения, заданные клиентом или оператором, не обогащаются
+	req := requestFor(*person)
+	req.CountryID = countryFor(w.cfg, *person, job.CountryID)
+	person.NormalizedName = req.Name
+	var enrichment Enrichment
+	if len(req.Attributes) > 0 {
+		enrichment, err = enrichWithTimeout(ctx, w.enricher, w.cfg.Timeout, req)
+	}
+	report := assessConfidence(w.cfg, &enrichment, req, err)
+	enrichment.apply(person)
+	person.LowConfidence = report.Flagged
+	if err == nil {
+		person.EnrichmentStatus = model.EnrichmentComplete
+		if err = w.persons.UpdateEnrichment(*person); err != nil {
+			slog.Error("Ошибка сохранения результатов обогащения", "job", job.ID, "error", err)
+			w.fail(job, err)
+			return
+		}
+		if err = w.jobs.CompleteEnrichmentJob(job.ID); err != nil {
+			slog.Error("Ошибка завершения задания на обогащение", "job", job.ID, "error", err)
+		}
+		slog.Info("Человек обогащен воркером", "job", job.ID, "person", person.ID)
+		return
+	}
+
+	slog.Warn("Ошибка фонового обогащения", "job", job.ID, "person", person.ID, "attempt", job.Attempts, "error", err)
+	if job.Attempts >= w.cfg.JobMaxAttempts {
+		// Сохраняем то, что удалось получить, и помечаем человека как необогащенного
+		person.EnrichmentStatus = model.EnrichmentFailed
+		if updErr := w.persons.UpdateEnrichment(*person); updErr != nil {
+			slog.Error("Ошибка сохранения результатов обогащения", "job", job.ID, "error", updErr)
+		}
+	}
+	w.fail(job, err)
+}
+
+// fail откладывает задание с экспоненциальной задержкой или переносит его в dead-letter
+func (w *EnrichmentWorker) fail(job model.EnrichmentJob, cause error) {
+	if job.Attempts >= w.cfg.JobMaxAttempts {
+		slog.Error("Задание на обогащение исчерпало попытки", "job", job.ID, "person", job.PersonID)
+		if err := w.jobs.DeadLetterEnrichmentJob(job.ID, cause.Error()); err != nil {
+			slog.Error("Ошибка переноса задания в dead-letter", "job", job.ID, "error", err)
+		}
+		return
+	}
+
+	runAt := time.Now().Add(w.cfg.JobRetryDelay << (job.Attempts - 1))
+	if err := w.jobs.RetryEnrichmentJob(job.ID, cause.Error(), runAt); err != nil {
+		slog.Error("Ошибка откладывания задания на обогащение", "job", job.ID, "error", err)
+	}
+}
@@ -15,6 +15,230 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/enrichment/cache/": {
+            "get": {
+                "description": "Возвращает число попаданий и промахов кеша обогащения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Статистика кеша обогащения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003cADMIN_TOKEN\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.CacheStats"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/enrichment/quota/": {
+            "get": {
+                "description": "Возвращает остаток квоты каждого провайдера по заголовкам X-Rate-Limit-* и число замедленных и отклоненных запросов",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Квоты внешних API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003cADMIN_TOKEN\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.QuotaState"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/enrichment/reenrich/": {
+            "post": {
+                "description": "Запускает в фоне повторное обогащение сохраненных людей по фильтру. Значения, заданные клиентом или оператором, не перезаписываются. В режиме dry_run изменения только перечисляются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Повторное обогащение",
+                "parameters": [
+                    {
+                        "description": "Фильтр и режим запуска",
+                        "name": "options",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ReenrichOptions"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003cADMIN_TOKEN\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/service.ReenrichRun"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/enrichment/reenrich/{id}/": {
+            "get": {
+                "description": "Возвращает статус и итог (промежуточный, пока запуск выполняется) повторного обогащения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Состояние повторного обогащения",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID запуска",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003cADMIN_TOKEN\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.ReenrichRun"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Метрики кеша обогащения и квот внешних API в текстовом формате Prometheus",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Метрики",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/person": {
             "post": {
                 "description": "Добавляет нового человека в БД с обогащением данными",
@@ -37,13 +261,25 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/model.Person"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Не использовать кеш обогащения",
+                        "name": "no_cache",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Страна (RU) или локаль (ru-RU) для уточнения возраста и пола",
+                        "name": "country_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/handler.SuccessResponse"
+                            "$ref": "#/definitions/handler.AddPersonResponse"
                         }
                     },
                     "400": {
@@ -52,18 +288,48 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
                     }
                 }
             }
         },
         "/person/{id}": {
             "put": {
-                "description": "Обновляет данные человека по ID",
+                "description": "Обновляет данные человека по ID. С If-Match обновление выполняется, только если версия человека не изменилась.",
                 "consumes": [
                     "application/json"
                 ],
@@ -82,6 +348,12 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag версии, которую клиент изменяет, или список ETag через запятую",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "Обновленные данные",
                         "name": "person",
@@ -97,6 +369,12 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/handler.SuccessResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия человека"
+                            }
                         }
                     },
                     "400": {
@@ -105,6 +383,30 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -114,7 +416,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Удаляет человека по ID",
+                "description": "Удаляет человека по ID. С If-Match человек удаляется, только если его версия не изменилась.",
                 "consumes": [
                     "application/json"
                 ],
@@ -132,6 +434,12 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии, которую клиент удаляет, или список ETag через запятую",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -147,6 +455,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -158,7 +484,7 @@ const docTemplate = `{
         },
         "/persons": {
             "get": {
-                "description": "Получение всех людей с пагинацией и фильтрами",
+                "description": "Получение всех людей с пагинацией и фильтрами. Страница выбирается номером (page) или курсором (after).\nС after ответ — объект PersonsPageResponse с items и next_cursor; пустой after начинает обход с первой страницы.\nБез after возвращается массив, а курсор следующей страницы передается в заголовке X-Next-Cursor;\nс envelope=true вместо массива возвращается PersonsPageResponse. Пустой список — всегда [].\nСсылки на соседние страницы передаются в Link (RFC 8288), общее число людей с total=exact или estimated — в X-Total-Count.",
                 "consumes": [
                     "application/json"
                 ],
@@ -180,10 +506,34 @@ const docTemplate = `{
                     {
                         "type": "integer",
                         "default": 10,
-                        "description": "Количество на странице",
+                        "description": "Количество на странице, не больше 100",
                         "name": "limit",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Курсор из next_cursor предыдущей страницы",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть объект с items, page, limit, total, next и prev вместо массива",
+                        "name": "envelope",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "none",
+                            "exact",
+                            "estimated"
+                        ],
+                        "type": "string",
+                        "default": "none",
+                        "description": "Подсчет общего числа: не считать, точно или оценкой по статистике БД",
+                        "name": "total",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Фильтр по имени",
@@ -191,21 +541,149 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
+                        "type": "string",
+                        "description": "Фильтр по подстроке фамилии",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по подстроке отчества",
+                        "name": "patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только люди без отчества",
+                        "name": "no_patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "male",
+                            "female"
+                        ],
                         "type": "string",
                         "description": "Фильтр по полу",
                         "name": "gender",
                         "in": "query"
                     },
+                    {
+                        "enum": [
+                            "male",
+                            "female"
+                        ],
+                        "type": "string",
+                        "description": "Пол не равен указанному (gender!=male); люди с неизвестным полом подходят",
+                        "name": "gender!",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Фильтр по национальности",
+                        "description": "Фильтр по национальности: один или несколько кодов страны через запятую (RU,UA,KZ)",
                         "name": "nationality",
                         "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Минимальный возраст",
+                        "name": "age_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальный возраст",
+                        "name": "age_max",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "complete",
+                            "pending",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Фильтр по статусу обогащения",
+                        "name": "enrichment_status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальная вероятность пола",
+                        "name": "min_gender_probability",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальная вероятность национальности",
+                        "name": "min_nationality_probability",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка через запятую, минус — по убыванию: surname,-age. Поля: id, name, surname, patronymic, age, gender, nationality",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Person"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки first, prev, next и last"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Курсор следующей страницы"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Общее число людей, подходящих под фильтр (total=exact или estimated)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/persons/bulk/": {
+            "post": {
+                "description": "Добавляет людей в БД, обогащая их пачками: одинаковые имена запрашиваются во внешних API один раз",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Person"
+                ],
+                "summary": "Массово добавить людей",
+                "parameters": [
+                    {
+                        "description": "Данные новых людей",
+                        "name": "persons",
+                        "in": "body",
+                        "required": true,
                         "schema": {
                             "type": "array",
                             "items": {
@@ -213,6 +691,249 @@ const docTemplate = `{
                             }
                         }
                     },
+                    {
+                        "type": "boolean",
+                        "description": "Не использовать кеш обогащения",
+                        "name": "no_cache",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Страна (RU) или локаль (ru-RU) для уточнения возраста и пола",
+                        "name": "country_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.BulkAddPersonsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/persons/{id}/": {
+            "get": {
+                "description": "Возвращает человека по ID. ETag ответа — версия человека; при совпадении с If-None-Match возвращается 304 без тела.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Person"
+                ],
+                "summary": "Получить человека",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID человека",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag ранее полученного ответа",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Person"
+                        }
+                    },
+                    "304": {
+                        "description": "Не изменился"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Изменяет только переданные поля: name, surname, patronymic, age, gender, nationality.\napplication/merge-patch+json (RFC 7396, по умолчанию): null удаляет значение.\napplication/json-patch+json (RFC 6902): операции с путями верхнего уровня, например /surname.\nИзмененные возраст, пол и национальность считаются исправленными оператором.\nС If-Match патч применяется, только если версия человека не изменилась.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Person"
+                ],
+                "summary": "Частично обновить человека",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID человека",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии, которую клиент изменяет, или список ETag через запятую",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Merge patch или массив операций JSON Patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Заново обогатить человека, если изменилось имя",
+                        "name": "reenrich",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Person"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия человека"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/persons/{id}/jobs/": {
+            "get": {
+                "description": "Возвращает историю заданий фонового обогащения человека",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Person"
+                ],
+                "summary": "Задания на обогащение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID человека",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.EnrichmentJob"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -224,6 +945,51 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "handler.AddPersonResponse": {
+            "type": "object",
+            "properties": {
+                "enrichment": {
+                    "$ref": "#/definitions/model.EnrichmentReport"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.BulkAddPersonItem": {
+            "type": "object",
+            "properties": {
+                "enrichment": {
+                    "$ref": "#/definitions/model.EnrichmentReport"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.BulkAddPersonsResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.BulkAddPersonItem"
+                    }
+                }
+            }
+        },
         "handler.ErrorResponse": {
             "type": "object",
             "properties": {
@@ -240,29 +1006,340 @@ const docTemplate = `{
                 }
             }
         },
+        "model.CountryPrediction": {
+            "type": "object",
+            "properties": {
+                "country_id": {
+                    "type": "string"
+                },
+                "probability": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.EnrichmentJob": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "country_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "person_id": {
+                    "type": "integer"
+                },
+                "run_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.EnrichmentReport": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "description": "Приняты",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "failed": {
+                    "description": "Не получены из-за ошибки провайдера",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "flagged": {
+                    "description": "Сохранены с пометкой низкой достоверности",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "person_id": {
+                    "type": "integer"
+                },
+                "provided": {
+                    "description": "Переданы клиентом и не обогащались",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rejected": {
+                    "description": "Отброшены как недостаточно достоверные",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
         "model.Person": {
             "type": "object",
             "properties": {
                 "age": {
                     "type": "integer"
                 },
+                "age_count": {
+                    "type": "integer"
+                },
+                "age_source": {
+                    "type": "string"
+                },
+                "enrichment_status": {
+                    "type": "string"
+                },
                 "gender": {
                     "type": "string"
                 },
+                "gender_probability": {
+                    "type": "number"
+                },
+                "gender_source": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "low_confidence": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "name": {
                     "type": "string"
                 },
+                "nationalities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CountryPrediction"
+                    }
+                },
                 "nationality": {
                     "type": "string"
                 },
+                "nationality_probability": {
+                    "type": "number"
+                },
+                "nationality_source": {
+                    "type": "string"
+                },
+                "normalized_name": {
+                    "type": "string"
+                },
                 "patronymic": {
                     "type": "string"
                 },
                 "surname": {
                     "type": "string"
+                },
+                "version": {
+                    "description": "Увеличивается при каждом изменении, отдается как ETag",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ReenrichFilter": {
+            "type": "object",
+            "properties": {
+                "enriched_before": {
+                    "description": "Последнее обогащение раньше этого времени или не выполнялось",
+                    "type": "string"
+                },
+                "from_id": {
+                    "type": "integer"
+                },
+                "limit": {
+                    "description": "Максимальное число людей",
+                    "type": "integer"
+                },
+                "missing": {
+                    "description": "Не заполнен хотя бы один из атрибутов",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sources": {
+                    "description": "Хотя бы один атрибут получен из одного из источников",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "to_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.CacheStats": {
+            "type": "object",
+            "properties": {
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.FieldChange": {
+            "type": "object",
+            "properties": {
+                "attribute": {
+                    "type": "string"
+                },
+                "new": {
+                    "type": "string"
+                },
+                "old": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.QuotaState": {
+            "type": "object",
+            "properties": {
+                "known": {
+                    "description": "Провайдер уже сообщил остаток квоты",
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "low": {
+                    "description": "Остаток не выше порога",
+                    "type": "boolean"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "rejected": {
+                    "description": "Запросы, не отправленные из-за квоты",
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "reset_at": {
+                    "type": "string"
+                },
+                "throttled": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.ReenrichOptions": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "description": "Только показать, что изменится",
+                    "type": "boolean"
+                },
+                "filter": {
+                    "$ref": "#/definitions/model.ReenrichFilter"
+                }
+            }
+        },
+        "service.ReenrichReport": {
+            "type": "object",
+            "properties": {
+                "changed": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ReenrichResult"
+                    }
+                }
+            }
+        },
+        "service.ReenrichResult": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.FieldChange"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "person_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.ReenrichRun": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "options": {
+                    "$ref": "#/definitions/service.ReenrichOptions"
+                },
+                "report": {
+                    "description": "Промежуточный итог, пока запуск выполняется",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.ReenrichReport"
+                        }
+                    ]
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         }
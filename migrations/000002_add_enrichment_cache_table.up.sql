@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS enrichment_cache (
+    key TEXT PRIMARY KEY,
+    payload JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS persons_enrichment_status_idx;
+ALTER TABLE persons DROP COLUMN IF EXISTS enrichment_status;
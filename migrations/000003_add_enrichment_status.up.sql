@@ -0,0 +1,2 @@
+ALTER TABLE persons ADD COLUMN IF NOT EXISTS enrichment_status VARCHAR(20) NOT NULL DEFAULT 'complete';
+CREATE INDEX IF NOT EXISTS persons_enrichment_status_idx ON persons (enrichment_status) WHERE enrichment_status <> 'complete';
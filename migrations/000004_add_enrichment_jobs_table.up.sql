@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS enrichment_jobs (
+    id SERIAL PRIMARY KEY,
+    person_id INTEGER NOT NULL REFERENCES persons (id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
+CREATE INDEX IF NOT EXISTS enrichment_jobs_ready_idx ON enrichment_jobs (run_at, id) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS enrichment_jobs_person_idx ON enrichment_jobs (person_id);
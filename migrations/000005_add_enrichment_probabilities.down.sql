@@ -0,0 +1,5 @@
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS age_count,
+    DROP COLUMN IF EXISTS gender_probability,
+    DROP COLUMN IF EXISTS nationality_probability,
+    DROP COLUMN IF EXISTS nationalities;
@@ -0,0 +1 @@
+ALTER TABLE persons ADD COLUMN IF NOT EXISTS low_confidence TEXT[];
@@ -0,0 +1,4 @@
+ALTER TABLE persons
+    ADD COLUMN IF NOT EXISTS age_source VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS gender_source VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS nationality_source VARCHAR(20);
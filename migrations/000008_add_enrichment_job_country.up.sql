@@ -0,0 +1 @@
+ALTER TABLE enrichment_jobs ADD COLUMN IF NOT EXISTS country_id VARCHAR(2);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS persons_normalized_name_idx;
+ALTER TABLE persons DROP COLUMN IF EXISTS normalized_name;
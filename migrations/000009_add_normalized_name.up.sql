@@ -0,0 +1,7 @@
+ALTER TABLE persons ADD COLUMN IF NOT EXISTS normalized_name TEXT;
+
+-- Имена в латинице нормализуются сразу; остальные заполняет приложение при запуске
+UPDATE persons SET normalized_name = lower(regexp_replace(btrim(name), '\s+', ' ', 'g'))
+WHERE normalized_name IS NULL AND name !~ '[^[:ascii:]]';
+
+CREATE INDEX IF NOT EXISTS persons_normalized_name_idx ON persons (normalized_name);
@@ -0,0 +1 @@
+ALTER TABLE persons ADD COLUMN IF NOT EXISTS enriched_at TIMESTAMPTZ;